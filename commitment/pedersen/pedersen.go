@@ -0,0 +1,241 @@
+// Package pedersen implements Pedersen commitments to ECgFp5 scalars and
+// vectors of scalars:
+//
+//	C = \sum_i v_i*G_i + r*H
+//
+// with a secret, uniformly random blinding r. Commitments are perfectly
+// hiding, and binding as long as no relation between the generators is
+// known. The generators are derived from a public seed string by hashing
+// to the curve, so that nobody knows their discrete logarithms (nothing
+// up my sleeve):
+//
+//	H   = HashToCurve([0], seed)
+//	G_i = HashToCurve([1, i], seed)
+//
+// Commitments are additively homomorphic: the sum of commitments to v and
+// v' with blindings r and r' is a commitment to v + v' with blinding
+// r + r'.
+package pedersen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+)
+
+// Seed of the default generators.
+const DefaultSeed = "poseidon_crypto/pedersen/v1"
+
+// Maximum number of value generators.
+const MaxGenerators = 1 << 16
+
+// Length of an encoded commitment, in bytes.
+const CommitmentSize = 40
+
+// Generators are the value generators G_i and the blinding generator H
+// derived from a seed. They are immutable and can be shared between
+// goroutines.
+type Generators struct {
+	seed string
+	g    []curve.ECgFp5Point
+	h    curve.ECgFp5Point
+
+	// Combs for G_0 and H, for commitments to a single value.
+	g0Table *curve.FixedBaseTable
+	hTable  *curve.FixedBaseTable
+}
+
+// NewGenerators derives n value generators and the blinding generator
+// from the seed.
+func NewGenerators(seed string, n int) (*Generators, error) {
+	if n < 1 || n > MaxGenerators {
+		return nil, fmt.Errorf("invalid number of generators, must be between 1 and %d", MaxGenerators)
+	}
+	domain := []byte(seed)
+	gens := &Generators{
+		seed:    seed,
+		g:       make([]curve.ECgFp5Point, n),
+		h:       curve.HashToCurve([]g.GoldilocksField{0}, domain),
+		g0Table: nil,
+		hTable:  nil,
+	}
+	for i := range gens.g {
+		gens.g[i] = curve.HashToCurve([]g.GoldilocksField{1, g.GoldilocksField(uint64(i))}, domain) //nolint:gosec
+	}
+	gens.g0Table = curve.NewFixedBaseTable(gens.g[0])
+	gens.hTable = curve.NewFixedBaseTable(gens.h)
+	return gens, nil
+}
+
+// Seed returns the seed of the generators.
+func (gens *Generators) Seed() string {
+	return gens.seed
+}
+
+// Len returns the number of value generators, i.e. the maximum length of
+// committed vectors.
+func (gens *Generators) Len() int {
+	return len(gens.g)
+}
+
+// G returns the value generator G_i.
+func (gens *Generators) G(i int) curve.ECgFp5Point {
+	return gens.g[i]
+}
+
+// H returns the blinding generator.
+func (gens *Generators) H() curve.ECgFp5Point {
+	return gens.h
+}
+
+// Commitment is a Pedersen commitment.
+type Commitment struct {
+	P curve.ECgFp5Point
+}
+
+// Opening is the secret opening of a commitment: the committed values and
+// the blinding.
+type Opening struct {
+	Values   []curve.ECgFp5Scalar
+	Blinding curve.ECgFp5Scalar
+}
+
+// Commit commits to the values (at most Len()), with a blinding sampled
+// from rand (or crypto/rand if rand is nil). The opening must be kept
+// secret until the commitment is opened.
+func (gens *Generators) Commit(rand io.Reader, values ...curve.ECgFp5Scalar) (Commitment, Opening, error) {
+	blinding, err := curve.SampleScalarFrom(rand)
+	if err != nil {
+		return Commitment{P: curve.NEUTRAL_ECgFp5Point}, Opening{Values: nil, Blinding: curve.ZERO}, err
+	}
+	c, err := gens.CommitWithBlinding(values, blinding)
+	if err != nil {
+		return Commitment{P: curve.NEUTRAL_ECgFp5Point}, Opening{Values: nil, Blinding: curve.ZERO}, err
+	}
+	return c, Opening{Values: append([]curve.ECgFp5Scalar(nil), values...), Blinding: blinding}, nil
+}
+
+// CommitWithBlinding commits to the values (at most Len()) with the
+// provided blinding, which must be a secret, uniformly random scalar for
+// the commitment to be hiding. This function is constant-time.
+func (gens *Generators) CommitWithBlinding(values []curve.ECgFp5Scalar, blinding curve.ECgFp5Scalar) (Commitment, error) {
+	if err := gens.checkOpening(values, blinding); err != nil {
+		return Commitment{P: curve.NEUTRAL_ECgFp5Point}, err
+	}
+	if len(values) == 1 {
+		return Commitment{P: gens.g0Table.Mul(values[0]).Add(gens.hTable.Mul(blinding))}, nil
+	}
+	points, scalars := gens.terms(values, blinding)
+	return Commitment{P: curve.MultiScalarMul(points, scalars)}, nil
+}
+
+// Verify checks that the opening matches the commitment. Openings are
+// public once revealed, so this function is vartime.
+func (gens *Generators) Verify(c Commitment, o Opening) bool {
+	if gens.checkOpening(o.Values, o.Blinding) != nil {
+		return false
+	}
+	points, scalars := gens.terms(o.Values, o.Blinding)
+	return curve.MultiScalarMulVarTime(points, scalars).Equals(c.P)
+}
+
+func (gens *Generators) checkOpening(values []curve.ECgFp5Scalar, blinding curve.ECgFp5Scalar) error {
+	if len(values) == 0 {
+		return errors.New("no values to commit to")
+	}
+	if len(values) > len(gens.g) {
+		return fmt.Errorf("too many values, at most %d", len(gens.g))
+	}
+	for i, v := range values {
+		if !v.IsCanonical() {
+			return fmt.Errorf("value %d is not canonical", i)
+		}
+	}
+	if !blinding.IsCanonical() {
+		return errors.New("blinding is not canonical")
+	}
+	return nil
+}
+
+// The terms of \sum_i v_i*G_i + r*H.
+func (gens *Generators) terms(values []curve.ECgFp5Scalar, blinding curve.ECgFp5Scalar) ([]curve.ECgFp5Point, []curve.ECgFp5Scalar) {
+	points := make([]curve.ECgFp5Point, 0, len(values)+1)
+	points = append(points, gens.g[:len(values)]...)
+	points = append(points, gens.h)
+	scalars := make([]curve.ECgFp5Scalar, 0, len(values)+1)
+	scalars = append(scalars, values...)
+	scalars = append(scalars, blinding)
+	return points, scalars
+}
+
+// Add returns the commitment to the sum of both openings.
+func (c Commitment) Add(rhs Commitment) Commitment {
+	return Commitment{P: c.P.Add(rhs.P)}
+}
+
+// Sub returns the commitment to the difference of both openings.
+func (c Commitment) Sub(rhs Commitment) Commitment {
+	return Commitment{P: c.P.Sub(rhs.P)}
+}
+
+// Equals reports whether both commitments are identical.
+func (c Commitment) Equals(rhs Commitment) bool {
+	return c.P.Equals(rhs.P)
+}
+
+// Bytes returns the 40-byte encoding of the commitment.
+func (c Commitment) Bytes() []byte {
+	return c.P.ToBytes()
+}
+
+// CommitmentFromBytes decodes a commitment from its 40-byte encoding.
+func CommitmentFromBytes(b []byte) (Commitment, error) {
+	if len(b) != CommitmentSize {
+		return Commitment{P: curve.NEUTRAL_ECgFp5Point}, fmt.Errorf("invalid commitment length, must be %d bytes", CommitmentSize)
+	}
+	p, err := curve.PointFromBytes(b)
+	if err != nil {
+		return Commitment{P: curve.NEUTRAL_ECgFp5Point}, fmt.Errorf("invalid commitment: %w", err)
+	}
+	return Commitment{P: p}, nil
+}
+
+// Add returns the opening of the sum of both commitments; the shorter
+// vector of values is padded with zeros. Both openings must be canonical
+// (e.g. checked with Verify).
+func (o Opening) Add(rhs Opening) Opening {
+	n := max(len(o.Values), len(rhs.Values))
+	values := make([]curve.ECgFp5Scalar, n)
+	for i := range values {
+		a, b := curve.ZERO, curve.ZERO
+		if i < len(o.Values) {
+			a = o.Values[i]
+		}
+		if i < len(rhs.Values) {
+			b = rhs.Values[i]
+		}
+		values[i] = a.Add(b)
+	}
+	return Opening{Values: values, Blinding: o.Blinding.Add(rhs.Blinding)}
+}
+
+// Sub returns the opening of the difference of both commitments, as Add.
+func (o Opening) Sub(rhs Opening) Opening {
+	n := max(len(o.Values), len(rhs.Values))
+	values := make([]curve.ECgFp5Scalar, n)
+	for i := range values {
+		a, b := curve.ZERO, curve.ZERO
+		if i < len(o.Values) {
+			a = o.Values[i]
+		}
+		if i < len(rhs.Values) {
+			b = rhs.Values[i]
+		}
+		values[i] = a.Sub(b)
+	}
+	blinding := o.Blinding
+	return Opening{Values: values, Blinding: blinding.Sub(rhs.Blinding)}
+}
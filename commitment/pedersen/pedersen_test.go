@@ -0,0 +1,225 @@
+package pedersen
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+)
+
+// Known-answer vectors for the default generators, to be matched by other
+// implementations.
+func TestGeneratorVectors(t *testing.T) {
+	gens, err := NewGenerators(DefaultSeed, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name     string
+		point    curve.ECgFp5Point
+		expected string
+	}{
+		{"H", gens.H(), "3a9eefaa4823da075e0a99cb1f87f8c5f2db2741d3627e2083379cc7b290c4dbeca5af985c578f11"},
+		{"G_0", gens.G(0), "e8cde6756b9a7ab0a93dedefa238c46bc63fd0cfa11b378650e582a39dc5f505cf184e1574456071"},
+		{"G_1", gens.G(1), "41c313134bbfcd46ca8e735cf5b0c61ada652841835140c3baa3ab3c32fa0413507a1e9464a22014"},
+	}
+	for _, tc := range testCases {
+		if got := hex.EncodeToString(tc.point.ToBytes()); got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	gens, err := NewGenerators(DefaultSeed, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gens.Len() != 8 || gens.Seed() != DefaultSeed {
+		t.Fatalf("unexpected generators metadata")
+	}
+
+	// All generators are distinct, and distinct from the curve generator.
+	points := []curve.ECgFp5Point{gens.H(), curve.GENERATOR_ECgFp5Point}
+	for i := 0; i < gens.Len(); i++ {
+		points = append(points, gens.G(i))
+	}
+	for i := range points {
+		if points[i].IsNeutral() {
+			t.Fatalf("generator %d is the neutral", i)
+		}
+		for j := 0; j < i; j++ {
+			if points[i].Equals(points[j]) {
+				t.Fatalf("generators %d and %d are equal", i, j)
+			}
+		}
+	}
+
+	// Generators only depend on the seed and the index.
+	shorter, _ := NewGenerators(DefaultSeed, 3)
+	if !shorter.G(2).Equals(gens.G(2)) || !shorter.H().Equals(gens.H()) {
+		t.Fatalf("generators should not depend on their number")
+	}
+	other, _ := NewGenerators("another seed", 1)
+	if other.G(0).Equals(gens.G(0)) || other.H().Equals(gens.H()) {
+		t.Fatalf("distinct seeds should yield distinct generators")
+	}
+
+	if _, err := NewGenerators(DefaultSeed, 0); err == nil {
+		t.Fatalf("zero generators should be rejected")
+	}
+	if _, err := NewGenerators(DefaultSeed, MaxGenerators+1); err == nil {
+		t.Fatalf("too many generators should be rejected")
+	}
+}
+
+func TestCommitVerify(t *testing.T) {
+	gens, _ := NewGenerators(DefaultSeed, 4)
+
+	for n := 1; n <= 4; n++ {
+		values := make([]curve.ECgFp5Scalar, n)
+		for i := range values {
+			values[i] = curve.SampleScalar()
+		}
+		c, o, err := gens.Commit(nil, values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gens.Verify(c, o) {
+			t.Fatalf("opening of %d values does not verify", n)
+		}
+
+		// Same as the definition.
+		expected := gens.H().Mul(o.Blinding)
+		for i, v := range values {
+			expected = expected.Add(gens.G(i).Mul(v))
+		}
+		if !c.P.Equals(expected) {
+			t.Fatalf("commitment to %d values does not match the definition", n)
+		}
+
+		wrong := Opening{Values: append([]curve.ECgFp5Scalar(nil), values...), Blinding: o.Blinding}
+		wrong.Values[n-1] = wrong.Values[n-1].Add(curve.ONE)
+		if gens.Verify(c, wrong) {
+			t.Fatalf("wrong value should not verify")
+		}
+		wrong = Opening{Values: values, Blinding: o.Blinding.Add(curve.ONE)}
+		if gens.Verify(c, wrong) {
+			t.Fatalf("wrong blinding should not verify")
+		}
+	}
+
+	// Commitments are hiding: the same value yields distinct commitments.
+	c1, _, _ := gens.Commit(nil, curve.ONE)
+	c2, _, _ := gens.Commit(nil, curve.ONE)
+	if c1.Equals(c2) {
+		t.Fatalf("commitments should be randomized")
+	}
+}
+
+func TestCommitRejects(t *testing.T) {
+	gens, _ := NewGenerators(DefaultSeed, 2)
+	nonCanonical := curve.ECgFp5Scalar{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+
+	if _, _, err := gens.Commit(nil); err == nil {
+		t.Fatalf("empty vectors should be rejected")
+	}
+	if _, _, err := gens.Commit(nil, curve.ONE, curve.ONE, curve.ONE); err == nil {
+		t.Fatalf("vectors longer than the generators should be rejected")
+	}
+	if _, err := gens.CommitWithBlinding([]curve.ECgFp5Scalar{nonCanonical}, curve.ONE); err == nil {
+		t.Fatalf("non-canonical values should be rejected")
+	}
+	if _, err := gens.CommitWithBlinding([]curve.ECgFp5Scalar{curve.ONE}, nonCanonical); err == nil {
+		t.Fatalf("non-canonical blindings should be rejected")
+	}
+	c, _ := gens.CommitWithBlinding([]curve.ECgFp5Scalar{curve.ONE}, curve.ONE)
+	if gens.Verify(c, Opening{Values: []curve.ECgFp5Scalar{curve.ONE}, Blinding: nonCanonical}) {
+		t.Fatalf("non-canonical openings should not verify")
+	}
+	if _, _, err := gens.Commit(bytes.NewReader(nil), curve.ONE); err == nil {
+		t.Fatalf("Commit should report a failing reader")
+	}
+}
+
+func TestHomomorphism(t *testing.T) {
+	gens, _ := NewGenerators(DefaultSeed, 3)
+
+	// Balance update: old + deposit - withdrawal.
+	c1, o1, _ := gens.Commit(nil, curve.ECgFp5Scalar{1000})
+	c2, o2, _ := gens.Commit(nil, curve.ECgFp5Scalar{250})
+	c3, o3, _ := gens.Commit(nil, curve.ECgFp5Scalar{75})
+	c := c1.Add(c2).Sub(c3)
+	o := o1.Add(o2).Sub(o3)
+	if !gens.Verify(c, o) {
+		t.Fatalf("sum of commitments does not verify")
+	}
+	if !o.Values[0].Equals(curve.ECgFp5Scalar{1175}) {
+		t.Fatalf("unexpected sum of values")
+	}
+
+	// Vectors of distinct lengths are padded with zeros.
+	v1, p1, _ := gens.Commit(nil, curve.ONE, curve.ONE, curve.ONE)
+	v2, p2, _ := gens.Commit(nil, curve.ONE)
+	sum := p1.Add(p2)
+	if len(sum.Values) != 3 || !gens.Verify(v1.Add(v2), sum) {
+		t.Fatalf("sum of vector commitments does not verify")
+	}
+	if !gens.Verify(v2.Sub(v1), p2.Sub(p1)) {
+		t.Fatalf("difference of vector commitments does not verify")
+	}
+}
+
+func TestCommitmentBytes(t *testing.T) {
+	gens, _ := NewGenerators(DefaultSeed, 1)
+	c, _, _ := gens.Commit(nil, curve.SampleScalar())
+
+	b := c.Bytes()
+	if len(b) != CommitmentSize {
+		t.Fatalf("expected %d bytes, got %d", CommitmentSize, len(b))
+	}
+	decoded, err := CommitmentFromBytes(b)
+	if err != nil || !decoded.Equals(c) {
+		t.Fatalf("commitment does not round-trip: %v", err)
+	}
+	if _, err := CommitmentFromBytes(b[:39]); err == nil {
+		t.Fatalf("short inputs should be rejected")
+	}
+}
+
+func BenchmarkCommit(b *testing.B) {
+	gens, _ := NewGenerators(DefaultSeed, 1)
+	v := curve.SampleScalar()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = gens.Commit(nil, v)
+	}
+}
+
+func BenchmarkCommitVector64(b *testing.B) {
+	gens, _ := NewGenerators(DefaultSeed, 64)
+	values := make([]curve.ECgFp5Scalar, 64)
+	for i := range values {
+		values[i] = curve.SampleScalar()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = gens.Commit(nil, values...)
+	}
+}
+
+func BenchmarkVerifyVector64(b *testing.B) {
+	gens, _ := NewGenerators(DefaultSeed, 64)
+	values := make([]curve.ECgFp5Scalar, 64)
+	for i := range values {
+		values[i] = curve.SampleScalar()
+	}
+	c, o, _ := gens.Commit(nil, values...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !gens.Verify(c, o) {
+			b.Fatal("opening does not verify")
+		}
+	}
+}
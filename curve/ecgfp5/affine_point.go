@@ -31,7 +31,8 @@ func (p *AffinePoint) SetNeg() {
 // Lookup a point in a window. The win[] slice must contain values
 // i*P for i = 1 to n (win[0] contains P, win[1] contains 2*P, and
 // so on). Index value k is an integer in the -n to n range; returned
-// point is k*P.
+// point is k*P. This function is constant-time: all window entries are
+// read, and selected with masks.
 func Lookup(win []AffinePoint, k int32) AffinePoint {
 	// sign = 0xFFFFFFFF if k < 0, 0x00000000 otherwise
 	sign := uint32(k >> 31) //nolint:gosec
@@ -46,19 +47,13 @@ func Lookup(win []AffinePoint, k int32) AffinePoint {
 		m := km1 - uint32(i) //nolint:gosec
 		c_1 := (m | (^m + 1)) >> 31
 		c := uint64(c_1) - 1
-		if c != 0 {
-			x = win[i].x
-			u = win[i].u
-		}
-
+		x = gFp5.Select(c, x, win[i].x)
+		u = gFp5.Select(c, u, win[i].u)
 	}
 
 	// If k < 0, then we must negate the point.
 	c := uint64(sign) | (uint64(sign) << 32)
-
-	if c != 0 {
-		u = gFp5.Neg(u)
-	}
+	u = gFp5.Select(c, u, gFp5.Neg(u))
 
 	return AffinePoint{x, u}
 }
@@ -0,0 +1,62 @@
+package ecgfp5
+
+import (
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+// CONSTANT-TIME OPERATIONS:
+//
+// The following operations are constant-time, i.e. their execution time
+// and memory access pattern do not depend on secret values; they are the
+// ones used with secret data (signing, key derivation, ECDH):
+//
+//   - scalar arithmetic: IsCanonical, Add, Sub, Neg, Mul, Square, Exp,
+//     Inverse, BatchInverse, MontyMul, Select, RecodeSigned, wide
+//     reduction (ScalarFromUint640, ScalarFromBytesReduced, HashToScalar)
+//   - point arithmetic: Add, Double, MDouble, AddAffine, Encode, Decode,
+//     Lookup, Mul, MulGen, FixedBaseTable.Mul, MultiScalarMul,
+//     BatchToAffine, MapToCurve, EncodeToCurve, HashToCurve
+//   - field arithmetic (quintic extension): InverseOrZero, Sqrt,
+//     CanonicalSqrt, Legendre, Sgn0, Select
+//
+// Conditional operations use masks (0 or 0xFFFFFFFFFFFFFFFF) instead of
+// branches. Note that the Goldilocks base field routines keep the
+// rarely-taken carry and borrow branches of the plonky2 implementation
+// (about one in 2^32 operations on random values).
+//
+// Functions with a "VarTime" suffix (and Lagrange, and the Weierstrass
+// representation) are NOT constant-time, and must only be used on public
+// values, e.g. for signature verification. Use the timing test harness
+// (go test -tags timing) to check for regressions.
+
+// Set this point to p0 if c == 0, or to p1 if c == 0xFFFFFFFFFFFFFFFF.
+func (p *ECgFp5Point) setSelect(c uint64, p0, p1 ECgFp5Point) {
+	p.x = gFp5.Select(c, p0.x, p1.x)
+	p.z = gFp5.Select(c, p0.z, p1.z)
+	p.u = gFp5.Select(c, p0.u, p1.u)
+	p.t = gFp5.Select(c, p0.t, p1.t)
+}
+
+// Return 0xFFFFFFFFFFFFFFFF if a is zero, 0 otherwise.
+func isZeroFp5(a gFp5.Element) uint64 {
+	var t uint64
+	for i := range a {
+		t |= a[i].ToCanonicalUint64()
+	}
+	return eqMask(t, 0)
+}
+
+// Return 0xFFFFFFFFFFFFFFFF if a == b, 0 otherwise.
+func eqMask(a, b uint64) uint64 {
+	t := a ^ b
+	return ((t | -t) >> 63) - 1
+}
+
+// Return 0xFFFFFFFFFFFFFFFF if c is true, 0 otherwise.
+func boolMask(c bool) uint64 {
+	var t uint64
+	if c {
+		t = 1
+	}
+	return -t
+}
@@ -1,6 +1,7 @@
 package ecgfp5
 
 import (
+	"encoding/binary"
 	"testing"
 
 	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
@@ -1444,3 +1445,518 @@ func TestWeierstrassMulAdd2(t *testing.T) {
 		}
 	}
 }
+
+func TestMulGen(t *testing.T) {
+	scalars := []ECgFp5Scalar{
+		ZERO,
+		ONE,
+		TWO,
+		NEG_ONE,
+		{
+			996458928865875995,
+			7368213710557165165,
+			8553572641065079816,
+			15282443801767955752,
+			251150557732720826,
+		},
+	}
+	for i := 0; i < 20; i++ {
+		scalars = append(scalars, SampleScalar())
+	}
+
+	for _, s := range scalars {
+		expected := GENERATOR_ECgFp5Point.Mul(s)
+		if !MulGen(s).Equals(expected) {
+			t.Fatalf("MulGen mismatch for scalar %v", s)
+		}
+	}
+
+	if !MulGen(ZERO).IsNeutral() {
+		t.Fatalf("Expected 0*G to be neutral")
+	}
+}
+
+func TestFixedBaseTableMul(t *testing.T) {
+	p := GENERATOR_ECgFp5Point.Mul(SampleScalar())
+	table := NewFixedBaseTable(p)
+	for i := 0; i < 10; i++ {
+		s := SampleScalar()
+		if !table.Mul(s).Equals(p.Mul(s)) {
+			t.Fatalf("FixedBaseTable.Mul mismatch for scalar %v", s)
+		}
+	}
+}
+
+func BenchmarkMulGenerator(b *testing.B) {
+	s := SampleScalar()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = GENERATOR_ECgFp5Point.Mul(s)
+	}
+}
+
+func BenchmarkMulGen(b *testing.B) {
+	s := SampleScalar()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = MulGen(s)
+	}
+}
+
+func TestVerifyMulAddVarTime(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		q := MulGen(SampleScalar())
+		s := SampleScalar()
+		k := SampleScalar()
+		r := MulGen(s).Add(q.Mul(k))
+
+		if !q.VerifyMulAddVarTime(s, k, r) {
+			t.Fatalf("Expected s*G + k*Q = R to verify")
+		}
+		if q.VerifyMulAddVarTime(s, k, r.Add(GENERATOR_ECgFp5Point)) {
+			t.Fatalf("Expected s*G + k*Q = R + G to be rejected")
+		}
+		if q.VerifyMulAddVarTime(s.Add(ONE), k, r) {
+			t.Fatalf("Expected (s+1)*G + k*Q = R to be rejected")
+		}
+	}
+
+	// Degenerate inputs.
+	if !NEUTRAL_ECgFp5Point.VerifyMulAddVarTime(ZERO, ZERO, NEUTRAL_ECgFp5Point) {
+		t.Fatalf("Expected 0*G + 0*N = N to verify")
+	}
+	if !GENERATOR_ECgFp5Point.VerifyMulAddVarTime(ONE, NEG_ONE, NEUTRAL_ECgFp5Point) {
+		t.Fatalf("Expected G - G = N to verify")
+	}
+}
+
+func TestMulAddGenVarTime(t *testing.T) {
+	// Short values of k (e.g. 128-bit challenges) skip the leading digits.
+	scalars := []ECgFp5Scalar{ZERO, ONE, NEG_ONE, {1 << 40}, {^uint64(0), ^uint64(0)}, {0, 0, 1}}
+	for i := 0; i < 20; i++ {
+		scalars = append(scalars, SampleScalar())
+	}
+
+	q := MulGen(SampleScalar())
+	for _, s := range scalars {
+		for _, k := range scalars[:8] {
+			expected := MulGen(s).Add(q.Mul(k))
+			if !q.MulAddGenVarTime(s, k).Equals(expected) {
+				t.Fatalf("MulAddGenVarTime mismatch for s=%v, k=%v", s, k)
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyMulAddVarTime(b *testing.B) {
+	q := MulGen(SampleScalar())
+	s := SampleScalar()
+	k := SampleScalar()
+	r := MulGen(s).Add(q.Mul(k))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = q.VerifyMulAddVarTime(s, k, r)
+	}
+}
+
+func BenchmarkMulAddGenVarTime(b *testing.B) {
+	q := MulGen(SampleScalar())
+	s := SampleScalar()
+	k := SampleScalar()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = q.MulAddGenVarTime(s, k)
+	}
+}
+
+// Baseline for the two functions above: the former verification path,
+// with 4-bit windows on both points.
+func BenchmarkWeierstrassMulAdd2(b *testing.B) {
+	q := MulGen(SampleScalar()).ToWeierstrass()
+	s := SampleScalar()
+	k := SampleScalar()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = MulAdd2(GENERATOR_WEIERSTRASS, q, s, k)
+	}
+}
+
+func TestFixedBaseTableMulAddGenVarTime(t *testing.T) {
+	q := MulGen(SampleScalar())
+	table := NewFixedBaseTable(q)
+	for i := 0; i < 10; i++ {
+		s := SampleScalar()
+		k := SampleScalar()
+		if !table.MulAddGenVarTime(s, k).Equals(q.MulAddGenVarTime(s, k)) {
+			t.Fatalf("FixedBaseTable.MulAddGenVarTime mismatch")
+		}
+	}
+	if !table.MulAddGenVarTime(ZERO, ZERO).IsNeutral() {
+		t.Fatalf("FixedBaseTable.MulAddGenVarTime should return the neutral for zero scalars")
+	}
+}
+
+func BenchmarkFixedBaseTableMulAddGenVarTime(b *testing.B) {
+	table := NewFixedBaseTable(MulGen(SampleScalar()))
+	s := SampleScalar()
+	k := SampleScalar()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = table.MulAddGenVarTime(s, k)
+	}
+}
+
+func naiveMultiScalarMul(points []ECgFp5Point, scalars []ECgFp5Scalar) ECgFp5Point {
+	res := NEUTRAL_ECgFp5Point
+	for i := range points {
+		res = res.Add(points[i].Mul(scalars[i]))
+	}
+	return res
+}
+
+func TestMultiScalarMul(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 17, 100} {
+		points := make([]ECgFp5Point, n)
+		scalars := make([]ECgFp5Scalar, n)
+		for i := 0; i < n; i++ {
+			points[i] = MulGen(SampleScalar())
+			scalars[i] = SampleScalar()
+		}
+		// Include edge cases: neutral points, zero and -1 scalars,
+		// repeated points.
+		if n > 2 {
+			points[0] = NEUTRAL_ECgFp5Point
+			scalars[1] = ZERO
+			scalars[2] = NEG_ONE
+			points[n-1] = points[n-2]
+		}
+
+		expected := naiveMultiScalarMul(points, scalars)
+		if !MultiScalarMulVarTime(points, scalars).Equals(expected) {
+			t.Fatalf("MultiScalarMulVarTime mismatch for n = %d", n)
+		}
+		if !MultiScalarMulVarTimeParallel(points, scalars, 4).Equals(expected) {
+			t.Fatalf("MultiScalarMulVarTimeParallel mismatch for n = %d", n)
+		}
+		if !MultiScalarMulVarTimeParallel(points, scalars, 0).Equals(expected) {
+			t.Fatalf("MultiScalarMulVarTimeParallel (default workers) mismatch for n = %d", n)
+		}
+		if !MultiScalarMul(points, scalars).Equals(expected) {
+			t.Fatalf("MultiScalarMul mismatch for n = %d", n)
+		}
+	}
+}
+
+func TestMultiScalarMulLargeWindow(t *testing.T) {
+	// 2^13 points select the largest window width; use small multiples of
+	// the generator so that the expected result is cheap to compute.
+	n := 1 << 13
+	points := make([]ECgFp5Point, n)
+	scalars := make([]ECgFp5Scalar, n)
+	expected := ZERO
+	p := GENERATOR_ECgFp5Point
+	for i := 0; i < n; i++ {
+		points[i] = p
+		p = p.Add(GENERATOR_ECgFp5Point)
+		scalars[i] = SampleScalar()
+		expected = expected.Add(scalars[i].Mul(ECgFp5Scalar{uint64(i) + 1, 0, 0, 0, 0})) //nolint:gosec
+	}
+
+	if !MultiScalarMulVarTimeParallel(points, scalars, 0).Equals(MulGen(expected)) {
+		t.Fatalf("MultiScalarMulVarTimeParallel mismatch for n = %d", n)
+	}
+}
+
+func benchmarkMultiScalarMul(b *testing.B, n int, f func([]ECgFp5Point, []ECgFp5Scalar) ECgFp5Point) {
+	points := make([]ECgFp5Point, n)
+	scalars := make([]ECgFp5Scalar, n)
+	p := MulGen(SampleScalar())
+	for i := 0; i < n; i++ {
+		points[i] = p
+		p = p.Add(GENERATOR_ECgFp5Point)
+		scalars[i] = SampleScalar()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f(points, scalars)
+	}
+}
+
+func BenchmarkMultiScalarMulVarTime1000(b *testing.B) {
+	benchmarkMultiScalarMul(b, 1000, MultiScalarMulVarTime)
+}
+
+func BenchmarkMultiScalarMulVarTimeParallel100000(b *testing.B) {
+	benchmarkMultiScalarMul(b, 100000, func(p []ECgFp5Point, s []ECgFp5Scalar) ECgFp5Point {
+		return MultiScalarMulVarTimeParallel(p, s, 0)
+	})
+}
+
+func BenchmarkMultiScalarMul1000(b *testing.B) {
+	benchmarkMultiScalarMul(b, 1000, MultiScalarMul)
+}
+
+// Check that the point is a valid representation of a group element: it
+// lies on the curve, and its x coordinate is not a square (or it is the
+// neutral).
+func isValidGroupPoint(p ECgFp5Point) bool {
+	if gFp5.IsZero(p.z) || gFp5.IsZero(p.t) {
+		return false
+	}
+	if p.IsNeutral() {
+		return gFp5.IsZero(p.x)
+	}
+	x := gFp5.Div(p.x, p.z)
+	y := gFp5.Div(gFp5.Mul(p.x, p.t), gFp5.Mul(p.z, p.u))
+	rhs := gFp5.Mul(x, gFp5.Add(gFp5.Mul(gFp5.Add(x, A_ECgFp5Point), x), B_ECgFp5Point))
+	return gFp5.Equals(gFp5.Square(y), rhs) && gFp5.Legendre(x).ToCanonicalUint64() != 1
+}
+
+func TestMapToCurve(t *testing.T) {
+	// Both the generator and generator + N (on the Weierstrass model)
+	// project onto the generator.
+	X, Y := GENERATOR_WEIERSTRASS.X, GENERATOR_WEIERSTRASS.Y
+	x := gFp5.Sub(X, aThird)
+	X2 := gFp5.Add(gFp5.Div(B_ECgFp5Point, x), aThird)
+	Y2 := gFp5.Neg(gFp5.Div(gFp5.Mul(B_ECgFp5Point, Y), gFp5.Square(x)))
+	for _, q := range [][2]gFp5.Element{{X, Y}, {X2, Y2}} {
+		p := projectFromWeierstrass(q[0], q[1])
+		if !isValidGroupPoint(p) || !p.Equals(GENERATOR_ECgFp5Point) {
+			t.Fatalf("projectFromWeierstrass: wrong point")
+		}
+	}
+	if !projectFromWeierstrass(aThird, gFp5.FP5_ZERO).IsNeutral() {
+		t.Fatalf("projectFromWeierstrass: N should map to the neutral")
+	}
+
+	for _, u := range []gFp5.Element{gFp5.FP5_ZERO, gFp5.FP5_ONE, gFp5.Sample(), gFp5.Sample(), gFp5.Sample()} {
+		p := MapToCurve(u)
+		if !isValidGroupPoint(p) {
+			t.Fatalf("MapToCurve: invalid point for u = %v", u)
+		}
+		q, ok := Decode(p.Encode())
+		if !ok || !q.Equals(p) || !gFp5.Equals(gFp5.Mul(p.x, q.z), gFp5.Mul(q.x, p.z)) {
+			t.Fatalf("MapToCurve: point does not round-trip through Encode/Decode")
+		}
+		if gFp5.IsZero(u) {
+			continue
+		}
+		// u and -u map to opposite points.
+		if !MapToCurve(gFp5.Neg(u)).Add(p).IsNeutral() {
+			t.Fatalf("MapToCurve: u and -u should map to opposite points")
+		}
+	}
+}
+
+func TestHashToCurveVectors(t *testing.T) {
+	input := []g.GoldilocksField{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	testCases := []struct {
+		input  []g.GoldilocksField
+		domain string
+		encode [5]uint64
+		hash   [5]uint64
+	}{
+		{
+			nil, "",
+			[5]uint64{15895306564518640295, 6235079113832562692, 4144957198011503988, 10729905568966285516, 8402542947162658281},
+			[5]uint64{6832183317175231183, 3255075346219373514, 4420225579752237348, 14686083836123428675, 8536942800997649138},
+		},
+		{
+			input[:1], "",
+			[5]uint64{5061581026761805968, 14649693089185096603, 1625876774421970618, 11445289137073196793, 11993541234236935162},
+			[5]uint64{12204148688932298729, 7363341438241961590, 5524446422126069198, 8860767259494279601, 2534643513075888788},
+		},
+		{
+			input, "",
+			[5]uint64{6421858319753753604, 7848600595672262973, 11932947861048363549, 8316549957094014608, 12793373751558180709},
+			[5]uint64{16904912500949719437, 11585690955678443947, 11510352872559418414, 2963683997428978992, 8654080354786192476},
+		},
+		{
+			input, "ECgFp5-test",
+			[5]uint64{8818439378538634253, 13414631418192297131, 13882772250037916626, 3469280154780602678, 13269522562561465725},
+			[5]uint64{16591616553133357323, 3608175430031204664, 8657310209048676971, 9675724883250246292, 16108621296285303670},
+		},
+		{
+			[]g.GoldilocksField{g.NegOneF(), 0}, "ECgFp5-test",
+			[5]uint64{201793831056154831, 6384952162676753471, 8170374427382917491, 15157742118502117278, 2059869041093131395},
+			[5]uint64{8211090607550554786, 15354320952460354049, 16110535219852380405, 2547971023839493045, 9272789518941319119},
+		},
+	}
+
+	for i, tc := range testCases {
+		encoded := EncodeToCurve(tc.input, []byte(tc.domain))
+		hashed := HashToCurve(tc.input, []byte(tc.domain))
+		if !isValidGroupPoint(encoded) || !isValidGroupPoint(hashed) {
+			t.Fatalf("test case %d: invalid point", i)
+		}
+		for j := 0; j < 5; j++ {
+			if encoded.Encode()[j].ToCanonicalUint64() != tc.encode[j] {
+				t.Fatalf("EncodeToCurve, test case %d: expected limb %d to be %d, got %d", i, j, tc.encode[j], encoded.Encode()[j])
+			}
+			if hashed.Encode()[j].ToCanonicalUint64() != tc.hash[j] {
+				t.Fatalf("HashToCurve, test case %d: expected limb %d to be %d, got %d", i, j, tc.hash[j], hashed.Encode()[j])
+			}
+		}
+	}
+
+	// Domains and lengths are separated.
+	if HashToCurve(input, []byte("a")).Equals(HashToCurve(input, []byte("b"))) ||
+		HashToCurve(input[:1], nil).Equals(HashToCurve([]g.GoldilocksField{1, 0}, nil)) ||
+		HashToCurve(input, nil).Equals(EncodeToCurve(input, nil)) {
+		t.Fatalf("HashToCurve: missing domain separation")
+	}
+}
+
+func BenchmarkHashToCurve(b *testing.B) {
+	input := []g.GoldilocksField{1, 2, 3, 4, 5}
+	domain := []byte("bench")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = HashToCurve(input, domain)
+	}
+}
+
+func TestNegSub(t *testing.T) {
+	p := GENERATOR_ECgFp5Point.Mul(SampleScalar())
+	q := GENERATOR_ECgFp5Point.Mul(SampleScalar())
+
+	if !p.Sub(p).IsNeutral() || !p.Add(p.Neg()).IsNeutral() {
+		t.Fatalf("P - P should be the neutral")
+	}
+	if !p.Add(q).Sub(q).Equals(p) {
+		t.Fatalf("(P + Q) - Q should be P")
+	}
+	if !NEUTRAL_ECgFp5Point.Neg().IsNeutral() || !NEUTRAL_ECgFp5Point.Sub(p).Equals(p.Neg()) {
+		t.Fatalf("wrong negation of the neutral")
+	}
+	k := SampleScalar()
+	if !GENERATOR_ECgFp5Point.Mul(k).Neg().Equals(GENERATOR_ECgFp5Point.Mul(k.Neg())) {
+		t.Fatalf("-(k*G) should be (-k)*G")
+	}
+
+	pw, qw := p.ToWeierstrass(), q.ToWeierstrass()
+	if !pw.Neg().Equals(p.Neg().ToWeierstrass()) || !pw.Sub(qw).Equals(p.Sub(q).ToWeierstrass()) {
+		t.Fatalf("WeierstrassPoint Neg/Sub do not match ECgFp5Point")
+	}
+	if !pw.Sub(pw).IsInf || !NEUTRAL_WEIERSTRASS.Neg().IsInf {
+		t.Fatalf("P - P should be the point at infinity")
+	}
+}
+
+func TestPointBytes(t *testing.T) {
+	for _, p := range []ECgFp5Point{NEUTRAL_ECgFp5Point, GENERATOR_ECgFp5Point, GENERATOR_ECgFp5Point.Mul(SampleScalar())} {
+		b := p.ToBytes()
+		if len(b) != 40 {
+			t.Fatalf("ToBytes: wrong length %d", len(b))
+		}
+		q, err := PointFromBytes(b)
+		if err != nil || !q.Equals(p) {
+			t.Fatalf("PointFromBytes: point does not round-trip: %v", err)
+		}
+
+		w, err := WeierstrassPointFromBytes(b)
+		if err != nil || !w.Equals(p.ToWeierstrass()) {
+			t.Fatalf("WeierstrassPointFromBytes: wrong point: %v", err)
+		}
+		wb := p.ToWeierstrass().ToBytes()
+		for i := range b {
+			if b[i] != wb[i] {
+				t.Fatalf("WeierstrassPoint.ToBytes: encoding differs from ECgFp5Point")
+			}
+		}
+	}
+
+	if _, err := PointFromBytes(make([]byte, 39)); err == nil {
+		t.Fatalf("PointFromBytes: short input should be rejected")
+	}
+
+	// The modulus is a non-canonical encoding of the zero limb; the
+	// neutral has the all-zero encoding.
+	b := NEUTRAL_ECgFp5Point.ToBytes()
+	binary.LittleEndian.PutUint64(b[16:], g.ORDER)
+	if _, err := PointFromBytes(b); err == nil {
+		t.Fatalf("PointFromBytes: non-canonical limb should be rejected")
+	}
+
+	// Find a field element which is not a point encoding.
+	for {
+		w := gFp5.Sample()
+		if canBeDecodedIntoPoint(w) {
+			continue
+		}
+		if _, err := PointFromBytes(w.ToLittleEndianBytes()); err == nil {
+			t.Fatalf("PointFromBytes: invalid encoding should be rejected")
+		}
+		if _, err := WeierstrassPointFromBytes(w.ToLittleEndianBytes()); err == nil {
+			t.Fatalf("WeierstrassPointFromBytes: invalid encoding should be rejected")
+		}
+		break
+	}
+}
+
+func TestWeierstrassConversion(t *testing.T) {
+	if !GENERATOR_ECgFp5Point.ToWeierstrass().Equals(GENERATOR_WEIERSTRASS) {
+		t.Fatalf("ToWeierstrass: wrong generator")
+	}
+	if !NEUTRAL_ECgFp5Point.ToWeierstrass().IsInf {
+		t.Fatalf("ToWeierstrass: neutral should map to the point at infinity")
+	}
+
+	for i := 0; i < 10; i++ {
+		p := GENERATOR_ECgFp5Point.Mul(SampleScalar())
+		w := p.ToWeierstrass()
+		expected, ok := DecodeFp5AsWeierstrass(p.Encode())
+		if !ok || !w.Equals(expected) || !w.IsOnCurve() {
+			t.Fatalf("ToWeierstrass: wrong point")
+		}
+		q, err := PointFromWeierstrass(w)
+		if err != nil || !q.Equals(p) || !isValidGroupPoint(q) {
+			t.Fatalf("PointFromWeierstrass: point does not round-trip: %v", err)
+		}
+		if _, err := NewWeierstrassPoint(w.X, w.Y); err != nil {
+			t.Fatalf("NewWeierstrassPoint: valid point rejected: %v", err)
+		}
+
+		// The curve point (x, y) satisfies y^2 = x*(x^2 + a*x + b), and
+		// is P + N, i.e. maps back to P on the Weierstrass model.
+		x, y := p.XY()
+		rhs := gFp5.Mul(x, gFp5.Add(gFp5.Mul(x, gFp5.Add(x, A_ECgFp5Point)), B_ECgFp5Point))
+		if !gFp5.Equals(gFp5.Square(y), rhs) {
+			t.Fatalf("XY: point is not on the curve")
+		}
+		if !projectFromWeierstrass(gFp5.Add(x, aThird), y).Equals(p) {
+			t.Fatalf("XY: wrong point")
+		}
+	}
+	x, y := NEUTRAL_ECgFp5Point.XY()
+	if !gFp5.IsZero(x) || !gFp5.IsZero(y) {
+		t.Fatalf("XY: neutral should be (0, 0)")
+	}
+	if p, err := PointFromWeierstrass(NEUTRAL_WEIERSTRASS); err != nil || !p.IsNeutral() {
+		t.Fatalf("PointFromWeierstrass: point at infinity should map to the neutral")
+	}
+
+	// Off-curve point.
+	X, Y := GENERATOR_WEIERSTRASS.X, GENERATOR_WEIERSTRASS.Y
+	if _, err := NewWeierstrassPoint(X, gFp5.Add(Y, gFp5.FP5_ONE)); err == nil {
+		t.Fatalf("NewWeierstrassPoint: off-curve point should be rejected")
+	}
+	// G + N is on the curve, but not in the prime-order group; neither
+	// is N itself.
+	x = gFp5.Sub(X, aThird)
+	X2 := gFp5.Add(gFp5.Div(B_ECgFp5Point, x), aThird)
+	Y2 := gFp5.Neg(gFp5.Div(gFp5.Mul(B_ECgFp5Point, Y), gFp5.Square(x)))
+	for _, q := range []WeierstrassPoint{{X: X2, Y: Y2, IsInf: false}, {X: aThird, Y: gFp5.FP5_ZERO, IsInf: false}} {
+		if !q.IsOnCurve() {
+			t.Fatalf("IsOnCurve: point should be on the curve")
+		}
+		if _, err := PointFromWeierstrass(q); err == nil {
+			t.Fatalf("PointFromWeierstrass: point outside of the group should be rejected")
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package ecgfp5
+
+import (
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+// Hashing into the group, following the structure of RFC 9380: field
+// elements are obtained from the Poseidon2 sponge, then mapped to the
+// short Weierstrass model y^2 = x^3 + A*x + B of the curve with the
+// simplified SWU map, and the resulting point is projected onto the
+// prime-order group.
+//
+// The short Weierstrass model is related to the curve equation
+// y^2 = x*(x^2 + a*x + b) through X = x + a/3 (y is unchanged); this is
+// the model used by WeierstrassPoint.
+
+// Domain separation tags for the hash-to-curve and encode-to-curve
+// variants: the ASCII strings "ecgfp5ro" and "ecgfp5nu", read as
+// little-endian 64-bit integers.
+const (
+	HASH_TO_CURVE_TAG   = g.GoldilocksField(0x6f72357066676365)
+	ENCODE_TO_CURVE_TAG = g.GoldilocksField(0x756e357066676365)
+)
+
+var (
+	// B = 2*a^3/27 - a*b/3 = 16/27 - (526/3)*z.
+	B_WEIERSTRASS = gFp5.Element{
+		g.GoldilocksField(15713893096167979237),
+		g.GoldilocksField(6148914689804861265),
+		g.GoldilocksField(0),
+		g.GoldilocksField(0),
+		g.GoldilocksField(0),
+	}
+
+	// Constant Z of the simplified SWU map: the non-square of the base
+	// field with the smallest absolute value (positive first) such that
+	// Z != -1, g(x) - Z is irreducible and g(B/(Z*A)) is a square, where
+	// g(x) = x^3 + A*x + B (RFC 9380, appendix H.2).
+	SSWU_Z = gFp5.Element{14, 0, 0, 0, 0}
+
+	sswuMinusBOverA = gFp5.Neg(gFp5.Div(B_WEIERSTRASS, A_WEIERSTRASS))
+	sswuBOverZA     = gFp5.Div(B_WEIERSTRASS, gFp5.Mul(SSWU_Z, A_WEIERSTRASS))
+	aThird          = gFp5.Div(A_ECgFp5Point, gFp5.FromUint64(3))
+)
+
+// EncodeToCurve maps the input to a group element, with a single
+// application of the map-to-curve:
+//
+//	u = HashToQuinticExtension(ENCODE_TO_CURVE_TAG || PackBytes(domain)
+//	                           || len(input) || input)
+//	P = MapToCurve(u)
+//
+// The output is not uniformly distributed over the group (some group
+// elements are not reachable); use HashToCurve() when the output must
+// behave as a random oracle.
+func EncodeToCurve(input []g.GoldilocksField, domain []byte) ECgFp5Point {
+	u := p2.HashToQuinticExtension(hashToCurveInput(ENCODE_TO_CURVE_TAG, input, domain))
+	return MapToCurve(u)
+}
+
+// HashToCurve maps the input to a group element whose discrete logarithm
+// is unknown, with an output distribution indistinguishable from uniform:
+//
+//	h = HashNToMNoPad(HASH_TO_CURVE_TAG || PackBytes(domain)
+//	                  || len(input) || input, 10)
+//	P = MapToCurve(h[0..5]) + MapToCurve(h[5..10])
+//
+// Distinct domains yield independent functions.
+func HashToCurve(input []g.GoldilocksField, domain []byte) ECgFp5Point {
+	h := p2.HashNToMNoPad(hashToCurveInput(HASH_TO_CURVE_TAG, input, domain), 10)
+	p0 := MapToCurve(gFp5.FromPlonky2GoldilocksField(h[:5]))
+	p1 := MapToCurve(gFp5.FromPlonky2GoldilocksField(h[5:]))
+	return p0.Add(p1)
+}
+
+// Build the (injectively encoded) sponge input for the hash-to-curve
+// functions.
+func hashToCurveInput(tag g.GoldilocksField, input []g.GoldilocksField, domain []byte) []g.GoldilocksField {
+	packedDomain := g.PackBytes(domain)
+	res := make([]g.GoldilocksField, 0, 2+len(packedDomain)+len(input))
+	res = append(res, tag)
+	res = append(res, packedDomain...)
+	res = append(res, g.GoldilocksField(uint64(len(input))))
+	res = append(res, input...)
+	return res
+}
+
+// MapToCurve maps a field element to a group element with the simplified
+// SWU map (RFC 9380, section 6.6.2) on the short Weierstrass model:
+//
+//  1. tv1 = 1/(Z^2*u^4 + Z*u^2), or 0 if the denominator is zero
+//  2. x1 = (-B/A)*(1 + tv1), or B/(Z*A) if tv1 = 0
+//  3. x2 = Z*u^2*x1
+//  4. (X, Y) = (x1, sqrt(g(x1))) if g(x1) is a square, else (x2, sqrt(g(x2)))
+//
+// where sqrt() is CanonicalSqrt(), and Y is negated if Sgn0(u) is true.
+// The curve point (X - a/3, Y) is then projected onto the prime-order
+// group: the group is the set of doubles 2*E, and each element P is
+// represented by P + N; the projection is thus the point itself if
+// X - a/3 is not a square, and the point plus N otherwise.
+//
+// This function has no data-dependent branches of its own; its timing is
+// then that of the field operations it uses.
+func MapToCurve(u gFp5.Element) ECgFp5Point {
+	zu2 := gFp5.Mul(SSWU_Z, gFp5.Square(u))
+	tv1 := gFp5.InverseOrZero(gFp5.Add(gFp5.Square(zu2), zu2))
+	x1 := gFp5.Mul(sswuMinusBOverA, gFp5.Add(gFp5.FP5_ONE, tv1))
+	x1 = gFp5.Select(isZeroFp5(tv1), x1, sswuBOverZA)
+	x2 := gFp5.Mul(zu2, x1)
+
+	y1, ok1 := gFp5.CanonicalSqrt(sswuCurveEquation(x1))
+	y2, _ := gFp5.CanonicalSqrt(sswuCurveEquation(x2))
+	c1 := boolMask(ok1)
+	x := gFp5.Select(c1, x2, x1)
+	y := gFp5.Select(c1, y2, y1)
+	y = gFp5.Select(gFp5.Sgn0Mask(u), y, gFp5.Neg(y))
+
+	return projectFromWeierstrass(x, y)
+}
+
+// Compute g(x) = x^3 + A*x + B.
+func sswuCurveEquation(x gFp5.Element) gFp5.Element {
+	return gFp5.Add(gFp5.Mul(gFp5.Add(gFp5.Square(x), A_WEIERSTRASS), x), B_WEIERSTRASS)
+}
+
+// Project the short Weierstrass point (X, Y) onto the prime-order group.
+// If x = X - a/3 is not a square, then the curve point (x, y) is the
+// representation of a group element; otherwise, the representation is
+// (x, y) + N = (b/x, -b*y/x^2), i.e. (x, u) = (b/x, -x/y) in fractional
+// coordinates. x = 0 means that (X, Y) maps to N, i.e. the neutral.
+func projectFromWeierstrass(X, Y gFp5.Element) ECgFp5Point {
+	x := gFp5.Sub(X, aThird)
+	ns := eqMask(gFp5.Legendre(x).ToCanonicalUint64(), g.ORDER-1)
+	p := ECgFp5Point{
+		x: gFp5.Select(ns, B_ECgFp5Point, x),
+		z: gFp5.Select(ns, x, gFp5.FP5_ONE),
+		u: gFp5.Select(ns, gFp5.Neg(x), x),
+		t: Y,
+	}
+	p.setSelect(isZeroFp5(x), p, NEUTRAL_ECgFp5Point)
+	return p
+}
@@ -0,0 +1,183 @@
+package ecgfp5
+
+import (
+	"math/bits"
+
+	. "github.com/elliottech/poseidon_crypto/int"
+)
+
+// Lagrange finds two signed integers c0 and c1 such that
+// k = c0/c1 mod n, with |c0| and |c1| both lower than 2^160 (and c1 != 0).
+//
+// This is lattice basis reduction in dimension 2, applied to the lattice
+// spanned by (n, 0) and (k, 1); we use algorithm 4 from
+// https://eprint.iacr.org/2020/454 (Pornin). The vector coordinates are
+// only tracked modulo 2^161: all updates are additions and subtractions,
+// and the coordinates of the returned vector fit in 161 bits, so the
+// truncated intermediate values still produce the exact result.
+//
+// WARNING: this function is vartime; do not use on secret values.
+func (k ECgFp5Scalar) Lagrange() (Signed161, Signed161) {
+	// Initialize the basis:
+	//   u = [n, 0]    nu = n^2
+	//   v = [k, 1]    nv = k^2 + 1
+	//   sp = u.v = n*k
+	u0 := Signed161{N[0], N[1], N[2]}
+	u1 := Signed161{}
+	v0 := Signed161{k[0], k[1], k[2]}
+	v1 := Signed161{1, 0, 0}
+	nu := Signed640FromMulU320(N, N)
+	nv := Signed640FromMulU320(k, k)
+	nv.AddUint64(1)
+	sp := Signed640FromMulU320(N, k)
+
+	for {
+		// Keep u as the longest vector.
+		if nu.LtUnsigned(&nv) {
+			u0, v0 = v0, u0
+			u1, v1 = v1, u1
+			nu, nv = nv, nu
+		}
+
+		// The shortest non-zero vector of the lattice has squared norm
+		// at most (2/sqrt(3))*n < 2^320. Once v is that short, both of
+		// its coordinates are lower than 2^160 in absolute value.
+		lenNv := nv.BitLength()
+		if lenNv <= 320 {
+			return v0, v1
+		}
+
+		// Subtract (or add) v*2^s from u, with s chosen so that the scalar
+		// product decreases as much as possible.
+		s := sp.BitLength() - lenNv
+		if s < 0 {
+			s = 0
+		}
+		if sp.IsNonNegative() {
+			u0.SubShifted(&v0, s)
+			u1.SubShifted(&v1, s)
+			nu.AddShifted(&nv, 2*s)
+			nu.SubShifted(&sp, s+1)
+			sp.SubShifted(&nv, s)
+		} else {
+			u0.AddShifted(&v0, s)
+			u1.AddShifted(&v1, s)
+			nu.AddShifted(&nv, 2*s)
+			nu.AddShifted(&sp, s+1)
+			sp.AddShifted(&nv, s)
+		}
+	}
+}
+
+// ScalarFromSigned161 converts a signed integer into a scalar (modulo n).
+func ScalarFromSigned161(v Signed161) ECgFp5Scalar {
+	x := v.ToU192()
+	if (x[2] >> 63) == 0 {
+		return ECgFp5Scalar{x[0], x[1], x[2], 0, 0}
+	}
+
+	// Negative value: compute n - |v|.
+	var c uint64
+	x[0], c = bits.Sub64(0, x[0], 0)
+	x[1], c = bits.Sub64(0, x[1], c)
+	x[2], _ = bits.Sub64(0, x[2], c)
+	r, _ := N.SubInner(ECgFp5Scalar{x[0], x[1], x[2], 0, 0})
+	return r
+}
+
+// VerifyMulAddVarTime checks whether s*G + k*Q = R, with Q being this
+// point and G the conventional generator.
+//
+// Scalar k is first split with Lagrange() into c0 and c1 such that
+// k = c0/c1 mod n; the equation is then equivalent to:
+//
+//	(s*c1)*G + c0*Q - c1*R = 0
+//
+// Since c0 and c1 are only about 160 bits each, and s*c1 is split over
+// the precomputed tables for G and 2^160*G, the combined multiplication
+// needs only 160 doublings (instead of about 320 for a plain s*G + k*Q).
+//
+// WARNING: this function is vartime; do not use on secret values.
+func (q ECgFp5Point) VerifyMulAddVarTime(s, k ECgFp5Scalar, r ECgFp5Point) bool {
+	c0, c1 := k.Lagrange()
+	t := s.Mul(ScalarFromSigned161(c1))
+
+	// Digits of t: the low 32 digits go with G, the high 32 digits with
+	// 2^160*G (sub-table 4 of the generator comb).
+	var tt [64]int32
+	t.RecodeSigned(tt[:], int32(WINDOW))
+	ss0 := RecodeSigned5(c0)
+	ss1 := RecodeSigned5(c1)
+
+	winG0 := generatorTable.tables[0][:]
+	winG160 := generatorTable.tables[160/COMB_SPACING][:]
+	winQ := q.MakeWindowAffine()
+	winR := r.MakeWindowAffine()
+
+	p := LookupVarTime(winQ, ss0[32]).ToPoint()
+	if ss1[32] != 0 {
+		p = p.AddAffine(LookupVarTime(winR, -ss1[32]))
+	}
+	for i := 31; i >= 0; i-- {
+		p.SetMDouble(uint32(WINDOW))
+		if tt[i] != 0 {
+			p = p.AddAffine(LookupVarTime(winG0, tt[i]))
+		}
+		if tt[i+32] != 0 {
+			p = p.AddAffine(LookupVarTime(winG160, tt[i+32]))
+		}
+		if ss0[i] != 0 {
+			p = p.AddAffine(LookupVarTime(winQ, ss0[i]))
+		}
+		if ss1[i] != 0 {
+			p = p.AddAffine(LookupVarTime(winR, -ss1[i]))
+		}
+	}
+
+	return p.IsNeutral()
+}
+
+// MulAddGenVarTime computes s*G + k*Q, with Q being this point and G the
+// conventional generator. The doublings are shared between both
+// multiplications, and the generator part uses the precomputed comb, so
+// this is about as expensive as a single variable-base multiplication.
+//
+// The leading zero digits of k are skipped: for a 128-bit k (e.g. the
+// challenge of short Schnorr signatures), only about 130 doublings are
+// needed instead of 320.
+//
+// WARNING: this function is vartime; do not use on secret values.
+func (q ECgFp5Point) MulAddGenVarTime(s, k ECgFp5Scalar) ECgFp5Point {
+	var ss, kk [COMB_TABLES * COMB_DIGITS]int32
+	s.RecodeSigned(ss[:], int32(WINDOW))
+	k.RecodeSigned(kk[:], int32(WINDOW))
+
+	// The loop must still go through the last COMB_DIGITS digits, which
+	// carry the generator part.
+	top := len(kk) - 1
+	for top >= COMB_DIGITS && kk[top] == 0 {
+		top--
+	}
+
+	winQ := q.MakeWindowAffine()
+	p := NEUTRAL_ECgFp5Point
+	for i := top; i >= 0; i-- {
+		if i < top {
+			p.SetMDouble(uint32(WINDOW))
+		}
+		if kk[i] != 0 {
+			p = p.AddAffine(LookupVarTime(winQ, kk[i]))
+		}
+		// Digit 8*j+i of s goes with sub-table j of the generator comb,
+		// and must then be doubled 5*i times.
+		if i < COMB_DIGITS {
+			for j := 0; j < COMB_TABLES; j++ {
+				if d := ss[j*COMB_DIGITS+i]; d != 0 {
+					p = p.AddAffine(LookupVarTime(generatorTable.tables[j][:], d))
+				}
+			}
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,151 @@
+package ecgfp5
+
+import (
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// MultiScalarMulVarTime computes \sum_i scalars[i]*points[i] with the
+// bucket method (Pippenger). Both slices must have the same length.
+//
+// WARNING: this function is vartime; do not use on secret scalars (see
+// MultiScalarMul() for a constant-time alternative).
+func MultiScalarMulVarTime(points []ECgFp5Point, scalars []ECgFp5Scalar) ECgFp5Point {
+	return MultiScalarMulVarTimeParallel(points, scalars, 1)
+}
+
+// MultiScalarMulVarTimeParallel is the same as MultiScalarMulVarTime(),
+// except that the bucket work of each window is spread over the
+// provided number of goroutines. If workers is zero or negative, then
+// runtime.GOMAXPROCS(0) goroutines are used.
+//
+// WARNING: this function is vartime; do not use on secret scalars.
+func MultiScalarMulVarTimeParallel(points []ECgFp5Point, scalars []ECgFp5Scalar, workers int) ECgFp5Point {
+	if len(points) != len(scalars) {
+		panic("MultiScalarMul: points and scalars must have the same length")
+	}
+	n := len(points)
+	if n == 0 {
+		return NEUTRAL_ECgFp5Point
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	w := msmWindowWidth(n)
+	numWindows := (320 + w - 1) / w
+
+	// Recode all scalars into signed digits in the -(2^(w-1)-1) to
+	// +2^(w-1) range; digit j of scalar i is digits[i*numWindows+j].
+	digits := make([]int32, n*numWindows)
+	for i, s := range scalars {
+		s.RecodeSigned(digits[i*numWindows:(i+1)*numWindows], int32(w)) //nolint:gosec
+	}
+	affine := BatchToAffine(points)
+
+	// Each window is processed independently.
+	sums := make([]ECgFp5Point, numWindows)
+	if workers > numWindows {
+		workers = numWindows
+	}
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			buckets := make([]ECgFp5Point, 1<<(w-1))
+			for j := k; j < numWindows; j += workers {
+				sums[j] = msmWindowSum(affine, digits, numWindows, j, buckets)
+			}
+		}(k)
+	}
+	wg.Wait()
+
+	// Combine the window sums: \sum_j 2^(w*j)*sums[j].
+	res := sums[numWindows-1]
+	for j := numWindows - 2; j >= 0; j-- {
+		res.SetMDouble(uint32(w)) //nolint:gosec
+		res = res.Add(sums[j])
+	}
+	return res
+}
+
+// Choose the window width for a bucket-method multi-scalar
+// multiplication over n points. RecodeSigned() supports widths up to 10.
+func msmWindowWidth(n int) int {
+	w := bits.Len(uint(n)) - 3 //nolint:gosec
+	if w < 2 {
+		return 2
+	}
+	if w > 10 {
+		return 10
+	}
+	return w
+}
+
+// Compute \sum_i d_i*P_i for the digits d_i of window j. Each point is
+// added (or subtracted) into the bucket of index |d_i| - 1; the buckets
+// are then aggregated with a running sum, so that bucket b is counted
+// b+1 times.
+func msmWindowSum(affine []AffinePoint, digits []int32, numWindows, j int, buckets []ECgFp5Point) ECgFp5Point {
+	for b := range buckets {
+		buckets[b] = NEUTRAL_ECgFp5Point
+	}
+	for i := range affine {
+		d := digits[i*numWindows+j]
+		if d > 0 {
+			buckets[d-1] = buckets[d-1].AddAffine(affine[i])
+		} else if d < 0 {
+			neg := affine[i]
+			neg.SetNeg()
+			buckets[-d-1] = buckets[-d-1].AddAffine(neg)
+		}
+	}
+
+	sum := NEUTRAL_ECgFp5Point
+	acc := NEUTRAL_ECgFp5Point
+	for b := len(buckets) - 1; b >= 0; b-- {
+		sum = sum.Add(buckets[b])
+		acc = acc.Add(sum)
+	}
+	return acc
+}
+
+// MultiScalarMul computes \sum_i scalars[i]*points[i]. Both slices must
+// have the same length. This function is constant-time with regard to
+// the scalars: it interleaves one 5-bit window per point (Straus), with
+// all table accesses going through Lookup().
+func MultiScalarMul(points []ECgFp5Point, scalars []ECgFp5Scalar) ECgFp5Point {
+	if len(points) != len(scalars) {
+		panic("MultiScalarMul: points and scalars must have the same length")
+	}
+	n := len(points)
+	if n == 0 {
+		return NEUTRAL_ECgFp5Point
+	}
+
+	// Make all windows with a single inversion.
+	tmp := make([]ECgFp5Point, n*WIN_SIZE)
+	for i, p := range points {
+		p.fillWindow(tmp[i*WIN_SIZE : (i+1)*WIN_SIZE])
+	}
+	win := BatchToAffine(tmp)
+
+	const numDigits = (319 + WINDOW) / WINDOW
+	digits := make([]int32, n*numDigits)
+	for i, s := range scalars {
+		s.RecodeSigned(digits[i*numDigits:(i+1)*numDigits], int32(WINDOW))
+	}
+
+	p := NEUTRAL_ECgFp5Point
+	for j := numDigits - 1; j >= 0; j-- {
+		if j != numDigits-1 {
+			p.SetMDouble(uint32(WINDOW))
+		}
+		for i := 0; i < n; i++ {
+			p = p.AddAffine(Lookup(win[i*WIN_SIZE:(i+1)*WIN_SIZE], digits[i*numDigits+j]))
+		}
+	}
+	return p
+}
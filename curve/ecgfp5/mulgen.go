@@ -0,0 +1,100 @@
+package ecgfp5
+
+const (
+	// Number of sub-tables in a fixed-base comb, and number of signed
+	// 5-bit digits handled by each of them. 8*8 digits of 5 bits cover
+	// the full 320-bit scalar range.
+	COMB_TABLES = 8
+	COMB_DIGITS = 8
+	// Bit distance between the bases of two consecutive sub-tables.
+	COMB_SPACING = COMB_DIGITS * WINDOW
+)
+
+// FixedBaseTable holds precomputed multiples of a fixed point P, laid out
+// as a multi-table comb (see mulgen() in Pornin's reference implementation).
+// Sub-table j contains i*(2^(40*j))*P for i = 1 to 16, in affine coordinates.
+//
+// Multiplying P by a scalar with such a table needs only 35 doublings
+// instead of about 320, at the cost of 64 table lookups and additions.
+// A table is immutable once built and can be shared between goroutines.
+type FixedBaseTable struct {
+	tables [COMB_TABLES][WIN_SIZE]AffinePoint
+}
+
+// Precomputed comb for the conventional generator, used by MulGen().
+var generatorTable = NewFixedBaseTable(GENERATOR_ECgFp5Point)
+
+// NewFixedBaseTable precomputes the comb tables for point p. This costs
+// about 280 doublings and 8 window computations, and thus only pays off
+// when p is multiplied by several scalars.
+func NewFixedBaseTable(p ECgFp5Point) *FixedBaseTable {
+	var ft FixedBaseTable
+	base := p
+	for j := 0; j < COMB_TABLES; j++ {
+		if j > 0 {
+			base.SetMDouble(COMB_SPACING)
+		}
+		copy(ft.tables[j][:], base.MakeWindowAffine())
+	}
+	return &ft
+}
+
+// Mul multiplies the table base point by scalar s. This function is
+// constant-time: all table accesses go through Lookup().
+func (ft *FixedBaseTable) Mul(s ECgFp5Scalar) ECgFp5Point {
+	// Scalar s is recoded into 64 signed digits d_i, so that
+	//   s = \sum_i d_i*2^(5*i)
+	// Digit d_(8*j+k) is then looked up in sub-table j, whose base is
+	// 2^(40*j)*P, and accounted for with k*5 doublings.
+	var digits [COMB_TABLES * COMB_DIGITS]int32
+	s.RecodeSigned(digits[:], int32(WINDOW))
+
+	p := Lookup(ft.tables[0][:], digits[COMB_DIGITS-1]).ToPoint()
+	for j := 1; j < COMB_TABLES; j++ {
+		p = p.AddAffine(Lookup(ft.tables[j][:], digits[j*COMB_DIGITS+COMB_DIGITS-1]))
+	}
+	for k := COMB_DIGITS - 2; k >= 0; k-- {
+		p.SetMDouble(uint32(WINDOW))
+		for j := 0; j < COMB_TABLES; j++ {
+			p = p.AddAffine(Lookup(ft.tables[j][:], digits[j*COMB_DIGITS+k]))
+		}
+	}
+
+	return p
+}
+
+// MulGen multiplies the conventional generator by scalar s. It returns the
+// same point as GENERATOR_ECgFp5Point.Mul(s), but uses static precomputed
+// tables and is several times faster. This function is constant-time.
+func MulGen(s ECgFp5Scalar) ECgFp5Point {
+	return generatorTable.Mul(s)
+}
+
+// MulAddGenVarTime computes s*G + k*P, with G the conventional generator
+// and P the base point of this table. Both multiplications use their comb
+// tables, so that only 35 doublings are needed; this is much faster than
+// P.MulAddGenVarTime(s, k) when the table is reused.
+//
+// WARNING: this function is vartime; do not use on secret values.
+func (ft *FixedBaseTable) MulAddGenVarTime(s, k ECgFp5Scalar) ECgFp5Point {
+	var ss, kk [COMB_TABLES * COMB_DIGITS]int32
+	s.RecodeSigned(ss[:], int32(WINDOW))
+	k.RecodeSigned(kk[:], int32(WINDOW))
+
+	p := NEUTRAL_ECgFp5Point
+	for i := COMB_DIGITS - 1; i >= 0; i-- {
+		if i < COMB_DIGITS-1 {
+			p.SetMDouble(uint32(WINDOW))
+		}
+		for j := 0; j < COMB_TABLES; j++ {
+			if d := ss[j*COMB_DIGITS+i]; d != 0 {
+				p = p.AddAffine(LookupVarTime(generatorTable.tables[j][:], d))
+			}
+			if d := kk[j*COMB_DIGITS+i]; d != 0 {
+				p = p.AddAffine(LookupVarTime(ft.tables[j][:], d))
+			}
+		}
+	}
+
+	return p
+}
@@ -1,6 +1,9 @@
 package ecgfp5
 
 import (
+	"encoding/binary"
+	"errors"
+
 	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
 	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
 )
@@ -91,6 +94,7 @@ func (p ECgFp5Point) Encode() gFp5.Element {
 // be in the prime-order group.
 //
 // Returns (point, true) on success, (NEUTRAL, false) on invalid encoding.
+// This function is constant-time.
 func Decode(w gFp5.Element) (ECgFp5Point, bool) {
 	// Curve equation is y^2 = x*(x^2 + a*x + b); encoded value
 	// is w = y/x. Dividing by x, we get the equation:
@@ -102,45 +106,142 @@ func Decode(w gFp5.Element) (ECgFp5Point, bool) {
 	e := gFp5.Sub(gFp5.Square(w), A_ECgFp5Point)
 	delta := gFp5.Sub(gFp5.Square(e), B_MUL4_ECgFp5Point)
 	r, c := gFp5.CanonicalSqrt(delta)
-	if !c {
-		r = gFp5.FP5_ZERO
-	}
+	// cm = 0xFFFFFFFFFFFFFFFF if delta is a square (then r is its root),
+	// 0 otherwise (then r is zero).
+	cm := boolMask(c)
 
 	x1 := gFp5.Div(gFp5.Add(e, r), gFp5.FP5_TWO)
 	x2 := gFp5.Div(gFp5.Sub(e, r), gFp5.FP5_TWO)
-	x := x1
+	x := gFp5.Select(eqMask(gFp5.Legendre(x1).ToCanonicalUint64(), 1), x1, x2)
 
-	x1Legendre := gFp5.Legendre(x1)
-	if x1Legendre.ToCanonicalUint64() == 1 {
-		x = x2
+	// If delta is not a square, then we want to get the neutral here;
+	// note that if w == 0, then delta = a^2 - 4*b, which is not a
+	// square, and thus we also get c == false.
+	p := ECgFp5Point{
+		x: gFp5.Select(cm, gFp5.FP5_ZERO, x),
+		z: gFp5.FP5_ONE,
+		u: gFp5.Select(cm, gFp5.FP5_ZERO, gFp5.FP5_ONE),
+		t: gFp5.Select(cm, gFp5.FP5_ONE, w),
 	}
 
-	// If c == true (delta is not a sqrt) then we want to get the neutral here; note that if
-	// w == 0, then delta = a^2 - 4*b, which is not a square, and
-	// thus we also get c == 0.
-	if !c {
-		x = gFp5.FP5_ZERO
+	// If w == 0 then this is in fact a success.
+	ok := cm | isZeroFp5(w)
+	p.setSelect(ok, NEUTRAL_ECgFp5Point, p)
+	return p, ok != 0
+}
+
+func (p ECgFp5Point) IsNeutral() bool {
+	return gFp5.IsZero(p.u)
+}
+
+// Neg returns the opposite of this point. For the representation P + N
+// of a group element P, the opposite of P is represented by -P + N, i.e.
+// the curve point (x, -y), which only changes the sign of u.
+func (p ECgFp5Point) Neg() ECgFp5Point {
+	return ECgFp5Point{
+		x: p.x,
+		z: p.z,
+		u: gFp5.Neg(p.u),
+		t: p.t,
 	}
-	z := gFp5.FP5_ONE
-	u := gFp5.FP5_ONE
-	if !c {
-		u = gFp5.FP5_ZERO
+}
+
+// Sub computes the group difference P ⊖ Q.
+func (p ECgFp5Point) Sub(rhs ECgFp5Point) ECgFp5Point {
+	return p.Add(rhs.Neg())
+}
+
+// ToBytes returns the canonical 40-byte encoding of this point, i.e. the
+// little-endian encoding of Encode().
+func (p ECgFp5Point) ToBytes() []byte {
+	return p.Encode().ToLittleEndianBytes()
+}
+
+// PointFromBytes decodes a point from its 40-byte encoding. Only the
+// output of ToBytes() is accepted: the five limbs must be canonical, and
+// the encoded field element must be the encoding of a group element.
+func PointFromBytes(b []byte) (ECgFp5Point, error) {
+	w, err := fp5FromCanonicalBytes(b)
+	if err != nil {
+		return NEUTRAL_ECgFp5Point, err
 	}
-	t := w
-	if !c {
-		t = gFp5.FP5_ONE
+	p, ok := Decode(w)
+	if !ok {
+		return NEUTRAL_ECgFp5Point, errors.New("invalid point encoding")
 	}
+	return p, nil
+}
 
-	// If w == 0 then this is in fact a success.
-	if c || gFp5.IsZero(w) {
-		return ECgFp5Point{x: x, z: z, u: u, t: t}, true
+// Parse a 40-byte Fp5 element, rejecting limbs that are not lower than
+// the Goldilocks modulus, so that each element has a single encoding.
+func fp5FromCanonicalBytes(b []byte) (gFp5.Element, error) {
+	if len(b) != gFp5.Bytes {
+		return gFp5.FP5_ZERO, errors.New("invalid point length, must be 40 bytes")
 	}
+	var w gFp5.Element
+	for i := range w {
+		limb := binary.LittleEndian.Uint64(b[i*g.Bytes:])
+		if limb >= g.ORDER {
+			return gFp5.FP5_ZERO, errors.New("point encoding is not canonical")
+		}
+		w[i] = g.GoldilocksField(limb)
+	}
+	return w, nil
+}
 
-	return NEUTRAL_ECgFp5Point, false
+// XY returns the affine coordinates (x, y) of the curve point which
+// represents this group element, i.e. the point P + N on the curve
+// y^2 = x*(x^2 + a*x + b) for the group element P. The neutral is
+// represented by N = (0, 0). Use ToWeierstrass() to get the coordinates
+// of P itself on the short Weierstrass model.
+func (p ECgFp5Point) XY() (x, y gFp5.Element) {
+	// x = X/Z and y = x/u = (X*T)/(Z*U); for the neutral, U = 0 and
+	// the inversion yields zero.
+	inv := gFp5.InverseOrZero(gFp5.Mul(p.z, p.u))
+	x = gFp5.Mul(gFp5.Mul(p.x, p.u), inv)
+	y = gFp5.Mul(gFp5.Mul(p.x, p.t), inv)
+	return x, y
 }
 
-func (p ECgFp5Point) IsNeutral() bool {
-	return gFp5.IsZero(p.u)
+// ToWeierstrass converts this point to the short Weierstrass model used
+// by the in-circuit representation. The group element P is represented by
+// the curve point R = P + N, hence P = R + N = (b/x, -b*y/x^2) on the
+// curve, and X = b/x + a/3 on the short Weierstrass model:
+//
+//	X = b*Z*U/(X*U) + a/3
+//	Y = -b*Z*T/(X*U)
+//
+// in the fractional coordinates of R.
+func (p ECgFp5Point) ToWeierstrass() WeierstrassPoint {
+	if p.IsNeutral() {
+		return NEUTRAL_WEIERSTRASS
+	}
+
+	inv := gFp5.InverseOrZero(gFp5.Mul(p.u, p.x))
+	bz := gFp5.Mul(B_ECgFp5Point, p.z)
+	return WeierstrassPoint{
+		X:     gFp5.Add(gFp5.Mul(gFp5.Mul(bz, p.u), inv), aThird),
+		Y:     gFp5.Neg(gFp5.Mul(gFp5.Mul(bz, p.t), inv)),
+		IsInf: false,
+	}
+}
+
+// PointFromWeierstrass converts a point of the short Weierstrass model to
+// the group. An error is returned if the point is not on the curve, or
+// not in the prime-order group (the Weierstrass model has order 2*n; its
+// points in the group are the ones for which X - a/3 is a non-zero
+// square).
+func PointFromWeierstrass(w WeierstrassPoint) (ECgFp5Point, error) {
+	if w.IsInf {
+		return NEUTRAL_ECgFp5Point, nil
+	}
+	if !w.IsOnCurve() {
+		return NEUTRAL_ECgFp5Point, errors.New("point is not on the curve")
+	}
+	if gFp5.Legendre(gFp5.Sub(w.X, aThird)).ToCanonicalUint64() != 1 {
+		return NEUTRAL_ECgFp5Point, errors.New("point is not in the prime-order group")
+	}
+	return projectFromWeierstrass(w.X, w.Y), nil
 }
 
 // Add computes the group sum P ⊕ Q using complete addition formulas.
@@ -437,18 +538,23 @@ func BatchToAffine(src []ECgFp5Point) []AffinePoint {
 
 func (p ECgFp5Point) MakeWindowAffine() []AffinePoint {
 	tmp := make([]ECgFp5Point, WIN_SIZE)
-	tmp[0] = p
-	for i := 1; i < WIN_SIZE; i++ {
+	p.fillWindow(tmp)
+	return BatchToAffine(tmp)
+}
+
+// Fill dst with i*P for i = 1 to len(dst) (dst[0] receives P).
+func (p ECgFp5Point) fillWindow(dst []ECgFp5Point) {
+	dst[0] = p
+	for i := 1; i < len(dst); i++ {
 		if (i & 1) == 0 {
-			tmp[i] = tmp[i-1].Add(p)
+			dst[i] = dst[i-1].Add(p)
 		} else {
-			tmp[i] = tmp[i>>1].Double()
+			dst[i] = dst[i>>1].Double()
 		}
 	}
-	return BatchToAffine(tmp)
 }
 
-// Multiply this point by a scalar.
+// Multiply this point by a scalar. This function is constant-time.
 func (r ECgFp5Point) Mul(s ECgFp5Scalar) ECgFp5Point {
 	p := r
 
@@ -457,7 +563,7 @@ func (r ECgFp5Point) Mul(s ECgFp5Scalar) ECgFp5Point {
 	digits := make([]int32, (319+WINDOW)/WINDOW)
 	s.RecodeSigned(digits, int32(WINDOW))
 
-	p = LookupVarTime(win, digits[len(digits)-1]).ToPoint()
+	p = Lookup(win, digits[len(digits)-1]).ToPoint()
 	for i := len(digits) - 2; i >= 0; i-- {
 		p.SetMDouble(uint32(WINDOW))
 		lookup := Lookup(win, digits[i])
@@ -3,9 +3,14 @@ package ecgfp5
 import (
 	cryptorand "crypto/rand"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
 	"math/big"
 
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
 	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
 	. "github.com/elliottech/poseidon_crypto/int"
 )
 
@@ -16,8 +21,12 @@ import (
 // p = 1067993516717146951041484916571792702745057740581727230159139685185762082554198619328292418486241
 type ECgFp5Scalar [5]uint64
 
+// IsCanonical returns true if the scalar is in the 0 to n-1 range. This
+// function is constant-time.
 func (s ECgFp5Scalar) IsCanonical() bool {
-	return ToNonCanonicalBigInt(s).Cmp(ORDER) < 0
+	// Subtracting n yields a borrow if and only if s < n.
+	_, c := s.SubInner(N)
+	return c != 0
 }
 
 var (
@@ -43,20 +52,90 @@ func (s ECgFp5Scalar) ToLittleEndianBytes() []byte {
 	return result
 }
 
+// ScalarElementFromLittleEndianBytes decodes the first 40 bytes of data as
+// an unsigned little-endian integer, reduced modulo n. It panics if data
+// is shorter than 40 bytes.
+//
+// Deprecated: non-canonical encodings are silently accepted, which makes
+// encoded values malleable. Use ScalarFromCanonicalBytes() to decode a
+// scalar, or ScalarFromBytesReduced() when reduction is intended.
 func ScalarElementFromLittleEndianBytes(data []byte) ECgFp5Scalar {
 	_ = data[39] // bounds check
+	return decodeReduce(data[:40])
+}
 
-	var value ECgFp5Scalar
+// ScalarFromCanonicalBytes decodes a scalar from its canonical encoding:
+// exactly 40 bytes, as an unsigned little-endian integer lower than n.
+// Any other input is rejected with an error, so that each scalar has a
+// single valid encoding.
+func ScalarFromCanonicalBytes(data []byte) (ECgFp5Scalar, error) {
+	if len(data) != 40 {
+		return ZERO, errors.New("invalid scalar length, must be 40 bytes")
+	}
+
+	var s ECgFp5Scalar
 	for i := 0; i < 5; i++ {
-		value[i] = binary.LittleEndian.Uint64(data[i*8:])
+		s[i] = binary.LittleEndian.Uint64(data[i*8:])
 	}
+	if !s.IsCanonical() {
+		return ZERO, errors.New("scalar is not canonical (must be lower than the group order)")
+	}
+	return s, nil
+}
+
+// ScalarFromBytesReduced interprets data (of any length) as an unsigned
+// little-endian integer, and reduces it modulo n. With at least 56
+// uniformly random bytes, the output is close to uniform over the
+// scalars (e.g. 80 bytes give a bias of about 2^-321). This function is
+// constant-time (for a given input length).
+func ScalarFromBytesReduced(data []byte) ECgFp5Scalar {
+	return decodeReduce(data)
+}
 
-	bigValue := ToNonCanonicalBigInt(value)
-	if bigValue.Cmp(ORDER) < 0 {
-		return value
+// Number of bytes processed at each step of decodeReduce(); the scalar
+// is multiplied by 2^(8*39) = 2^312 with a single Montgomery
+// multiplication by T632.
+const decodeReduceChunk = 39
+
+// Interpret the provided bytes as an unsigned integer in little-endian
+// convention (of any length), and reduce it modulo n. This function is
+// constant-time (for a given input length).
+func decodeReduce(buf []byte) ECgFp5Scalar {
+	r := ZERO
+	if len(buf) == 0 {
+		return r
 	}
 
-	return FromNonCanonicalBigInt(bigValue)
+	// Process the input by chunks of 39 bytes, starting with the top
+	// chunk, which may be shorter: r <- r*2^312 + chunk. Since
+	// r < n and chunk < 2^312 < n, a single conditional subtraction of
+	// n (in Add()) keeps r reduced.
+	end := len(buf)
+	start := end - ((end-1)%decodeReduceChunk + 1)
+	for end > 0 {
+		var tmp [40]byte
+		copy(tmp[:], buf[start:end])
+		var chunk ECgFp5Scalar
+		for i := 0; i < 5; i++ {
+			chunk[i] = binary.LittleEndian.Uint64(tmp[i*8:])
+		}
+		r = r.MontyMul(T632).Add(chunk)
+		end = start
+		start -= decodeReduceChunk
+	}
+	return r
+}
+
+// ScalarFromUint640 reduces a 640-bit unsigned integer (ten 64-bit limbs,
+// in little-endian order) modulo n. With a uniformly random input, the
+// output is uniform over the scalars, up to a negligible bias (about
+// 2^-321). This function is constant-time.
+func ScalarFromUint640(v [10]uint64) ECgFp5Scalar {
+	var buf [80]byte
+	for i := 0; i < 10; i++ {
+		binary.LittleEndian.PutUint64(buf[i*8:], v[i])
+	}
+	return decodeReduce(buf[:])
 }
 
 func (s ECgFp5Scalar) SplitTo4BitLimbs() [80]uint8 {
@@ -69,12 +148,46 @@ func (s ECgFp5Scalar) SplitTo4BitLimbs() [80]uint8 {
 	return result
 }
 
+// SplitTo32BitLimbs returns the scalar as ten 32-bit limbs, in
+// little-endian order. Each limb fits in a Goldilocks field element, so
+// this is an injective encoding of the scalar for hashing.
+func (s ECgFp5Scalar) SplitTo32BitLimbs() [10]g.GoldilocksField {
+	var result [10]g.GoldilocksField
+	for i := 0; i < 5; i++ {
+		result[2*i] = g.GoldilocksField(s[i] & 0xFFFFFFFF)
+		result[2*i+1] = g.GoldilocksField(s[i] >> 32)
+	}
+	return result
+}
+
+// SampleScalar returns a uniformly random scalar, obtained by reducing
+// 640 random bits modulo n.
 func SampleScalar() ECgFp5Scalar {
-	rng, err := cryptorand.Int(cryptorand.Reader, ORDER)
-	if err != nil {
+	var buf [80]byte
+	if _, err := cryptorand.Read(buf[:]); err != nil {
 		panic("failed to read random bytes into buffer")
 	}
-	return FromNonCanonicalBigInt(rng)
+	return decodeReduce(buf[:])
+}
+
+// SampleScalarFrom samples a non-zero scalar by reducing 80 bytes read from
+// rand (or crypto/rand if rand is nil); the bias is negligible. It is meant
+// for secret keys and blindings, and returns an error instead of panicking
+// if rand fails.
+func SampleScalarFrom(rand io.Reader) (ECgFp5Scalar, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var buf [80]byte
+	for {
+		if _, err := io.ReadFull(rand, buf[:]); err != nil {
+			return ZERO, fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		// Zero is unreachable in practice, but rejected anyway.
+		if s := decodeReduce(buf[:]); !s.Equals(ZERO) {
+			return s, nil
+		}
+	}
 }
 
 var (
@@ -144,10 +257,7 @@ func (s ECgFp5Scalar) SubInner(a ECgFp5Scalar) (ECgFp5Scalar, uint64) {
 		c = z.Hi & 1
 	}
 
-	if c != 0 {
-		return r, 0xFFFFFFFFFFFFFFFF
-	}
-	return r, 0
+	return r, -c
 }
 
 // If c == 0, return a0.
@@ -253,24 +363,136 @@ func (s ECgFp5Scalar) MontyMul(rhs ECgFp5Scalar) ECgFp5Scalar {
 	return Select(c, r2, r)
 }
 
+// Neg returns -s mod n. 's' must be canonical.
+func (s ECgFp5Scalar) Neg() ECgFp5Scalar {
+	r := ZERO
+	return r.Sub(s)
+}
+
+// Square returns s^2 mod n. 's' must be canonical.
+func (s ECgFp5Scalar) Square() ECgFp5Scalar {
+	return s.Mul(s)
+}
+
+// Exp returns s^e mod n, for a canonical 's' and an exponent e in the 0 to
+// 2^320-1 range (e does not need to be canonical). This function is
+// constant-time with regard to both s and e.
+func (s ECgFp5Scalar) Exp(e ECgFp5Scalar) ECgFp5Scalar {
+	if !s.IsCanonical() {
+		panic("Exp: operand 's' must be canonical (< n)")
+	}
+
+	// Computations are done in Montgomery representation (x*2^320 mod n),
+	// with a 4-bit window: win[i] = s^i.
+	var win [16]ECgFp5Scalar
+	win[0] = R2.MontyMul(ONE)
+	win[1] = s.MontyMul(R2)
+	for i := 2; i < len(win); i++ {
+		win[i] = win[i-1].MontyMul(win[1])
+	}
+
+	r := win[0]
+	for i := 79; i >= 0; i-- {
+		for j := 0; j < 4; j++ {
+			r = r.MontyMul(r)
+		}
+		d := (e[i>>4] >> (4 * uint(i&15))) & 0xF
+		t := win[0]
+		for k := 1; k < len(win); k++ {
+			t = Select(eqMask(d, uint64(k)), t, win[k]) //nolint:gosec
+		}
+		r = r.MontyMul(t)
+	}
+
+	// Convert back from Montgomery representation.
+	return r.MontyMul(ONE)
+}
+
+var nMinusTwo = ECgFp5Scalar{N[0] - 2, N[1], N[2], N[3], N[4]}
+
+// Inverse returns 1/s mod n (Fermat's little theorem: s^(n-2)); the
+// inverse of zero is zero. 's' must be canonical. This function is
+// constant-time.
+func (s ECgFp5Scalar) Inverse() ECgFp5Scalar {
+	return s.Exp(nMinusTwo)
+}
+
+// BatchInverse returns the inverses of all provided scalars, with a single
+// inversion (Montgomery's trick). As with Inverse(), the inverse of zero
+// is zero. All scalars must be canonical. This function is constant-time.
+func BatchInverse(s []ECgFp5Scalar) []ECgFp5Scalar {
+	if len(s) == 0 {
+		return nil
+	}
+
+	// Zeros are replaced with ones, so that they don't spoil the product
+	// of all other values; their output is set back to zero at the end.
+	res := make([]ECgFp5Scalar, len(s))
+	acc := ONE
+	for i, x := range s {
+		res[i] = acc
+		acc = acc.Mul(Select(scalarIsZero(x), x, ONE))
+	}
+	acc = acc.Inverse()
+	for i := len(s) - 1; i >= 0; i-- {
+		z := scalarIsZero(s[i])
+		x := Select(z, s[i], ONE)
+		res[i] = Select(z, res[i].Mul(acc), ZERO)
+		acc = acc.Mul(x)
+	}
+	return res
+}
+
+// Return 0xFFFFFFFFFFFFFFFF if s is zero, 0 otherwise.
+func scalarIsZero(s ECgFp5Scalar) uint64 {
+	return eqMask(s[0]|s[1]|s[2]|s[3]|s[4], 0)
+}
+
+// HashToScalar hashes the input with Poseidon2 into a scalar: ten
+// Goldilocks elements are squeezed from the sponge and reduced, as a
+// 640-bit integer, modulo n (see ScalarFromUint640()). The output is
+// uniform over the scalars, up to a negligible bias. Callers are expected
+// to include their own domain separation in the input.
+func HashToScalar(input []g.GoldilocksField) ECgFp5Scalar {
+	h := p2.HashNToMNoPad(input, 10)
+	var wide [10]uint64
+	for i := range wide {
+		wide[i] = h[i].ToCanonicalUint64()
+	}
+	return ScalarFromUint640(wide)
+}
+
+// FromGfp5 interprets the (canonical) limbs of an Fp5 element as a
+// 320-bit unsigned integer, and reduces it modulo n. This function is
+// constant-time.
 func FromGfp5(fp5 gFp5.Element) ECgFp5Scalar {
-	result := new(big.Int)
-	for i := 4; i >= 0; i-- {
-		result.Lsh(result, 64)
-		result.Or(result, new(big.Int).SetUint64(fp5[i].ToCanonicalUint64()))
+	var r ECgFp5Scalar
+	for i := 0; i < 5; i++ {
+		r[i] = fp5[i].ToCanonicalUint64()
 	}
 
-	return FromNonCanonicalBigInt(result)
+	// The value is lower than 2^320 < 3*n, so two conditional
+	// subtractions of n are enough.
+	for i := 0; i < 2; i++ {
+		r2, c := r.SubInner(N)
+		r = Select(c, r2, r)
+	}
+	return r
 }
 
-// Warn: This won't work in 32-bit systems!
+// FromNonCanonicalBigInt reduces an integer (possibly negative, and of
+// any size) modulo n.
 func FromNonCanonicalBigInt(val *big.Int) ECgFp5Scalar {
-	limbs := new(big.Int).Mod(val, ORDER).Bits()
-	if len(limbs) < 5 {
-		limbs = append(limbs, make([]big.Word, 5-len(limbs))...)
+	buf := val.Bytes()
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
 	}
 
-	return ECgFp5Scalar{uint64(limbs[0]), uint64(limbs[1]), uint64(limbs[2]), uint64(limbs[3]), uint64(limbs[4])} // assuming big.Word is 64 bits
+	r := decodeReduce(buf)
+	if val.Sign() < 0 {
+		return ZERO.Sub(r)
+	}
+	return r
 }
 
 func ToNonCanonicalBigInt(s ECgFp5Scalar) *big.Int {
@@ -287,7 +509,7 @@ func ToNonCanonicalBigInt(s ECgFp5Scalar) *big.Int {
 // provided slice is filled; if w*len(ss) >= 320, then the output
 // encodes the complete scalar value, and the top (last) signed
 // integer is nonnegative.
-// Window width MUST be between 2 and 10.
+// Window width MUST be between 2 and 10. This function is constant-time.
 func (s ECgFp5Scalar) RecodeSigned(ss []int32, w int32) {
 	RecodeSignedFromLimbs(s[:], ss, w)
 }
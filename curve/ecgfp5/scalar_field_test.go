@@ -1,11 +1,15 @@
 package ecgfp5
 
 import (
+	"bytes"
 	"math/big"
+	"math/rand/v2"
 	"testing"
 
 	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
 	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+	. "github.com/elliottech/poseidon_crypto/int"
 )
 
 func TestSerdes(t *testing.T) {
@@ -409,3 +413,274 @@ func TestNonCanonicalInputsRejected(t *testing.T) {
 		}
 	})
 }
+
+func signed161ToBigInt(v Signed161) *big.Int {
+	x := v.ToU192()
+	r := new(big.Int)
+	for i := 2; i >= 0; i-- {
+		r.Lsh(r, 64)
+		r.Or(r, new(big.Int).SetUint64(x[i]))
+	}
+	if (x[2] >> 63) != 0 {
+		r.Sub(r, new(big.Int).Lsh(big.NewInt(1), 192))
+	}
+	return r
+}
+
+func TestLagrange(t *testing.T) {
+	bound := new(big.Int).Lsh(big.NewInt(1), 160)
+	scalars := []ECgFp5Scalar{ZERO, ONE, TWO, NEG_ONE}
+	for i := 0; i < 100; i++ {
+		scalars = append(scalars, SampleScalar())
+	}
+
+	for _, k := range scalars {
+		c0, c1 := k.Lagrange()
+		c0Big := signed161ToBigInt(c0)
+		c1Big := signed161ToBigInt(c1)
+
+		if c1Big.Sign() == 0 {
+			t.Fatalf("Lagrange(%v): c1 is zero", k)
+		}
+		if new(big.Int).Abs(c0Big).Cmp(bound) >= 0 || new(big.Int).Abs(c1Big).Cmp(bound) >= 0 {
+			t.Fatalf("Lagrange(%v): output too large: c0=%v, c1=%v", k, c0Big, c1Big)
+		}
+
+		// c0 = k*c1 mod n
+		lhs := new(big.Int).Mod(c0Big, ORDER)
+		rhs := new(big.Int).Mul(ToNonCanonicalBigInt(k), c1Big)
+		rhs.Mod(rhs, ORDER)
+		if lhs.Cmp(rhs) != 0 {
+			t.Fatalf("Lagrange(%v): c0 != k*c1 mod n", k)
+		}
+
+		if !ScalarFromSigned161(c1).Equals(FromNonCanonicalBigInt(c1Big)) {
+			t.Fatalf("ScalarFromSigned161 mismatch for %v", c1Big)
+		}
+	}
+}
+
+func TestSplitTo32BitLimbs(t *testing.T) {
+	scalar := ECgFp5Scalar{
+		0x0123456789ABCDEF,
+		0xFEDCBA9876543210,
+		0,
+		0xFFFFFFFF00000000,
+		0x7FFFFFFD80000007,
+	}
+	expected := [10]uint64{
+		0x89ABCDEF, 0x01234567,
+		0x76543210, 0xFEDCBA98,
+		0, 0,
+		0, 0xFFFFFFFF,
+		0x80000007, 0x7FFFFFFD,
+	}
+
+	for i, limb := range scalar.SplitTo32BitLimbs() {
+		if uint64(limb) != expected[i] {
+			t.Fatalf("Expected limb %d to be %x, but got %x", i, expected[i], uint64(limb))
+		}
+	}
+}
+
+func TestIsCanonical(t *testing.T) {
+	nMinusOne, _ := N.SubInner(ONE)
+	nPlusOne := N.AddInner(ONE)
+	testCases := []struct {
+		s        ECgFp5Scalar
+		expected bool
+	}{
+		{ZERO, true},
+		{nMinusOne, true},
+		{N, false},
+		{nPlusOne, false},
+		{ECgFp5Scalar{0, 0, 0, 0, 0x8000000000000000}, false},
+		{ECgFp5Scalar{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}, false},
+	}
+	for i, tc := range testCases {
+		if tc.s.IsCanonical() != tc.expected {
+			t.Fatalf("IsCanonical, test case %d: expected %v", i, tc.expected)
+		}
+	}
+}
+
+func TestWideReduction(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		var v [10]uint64
+		for j := range v {
+			v[j] = rand.Uint64() //nolint:gosec
+		}
+		if i == 0 {
+			for j := range v {
+				v[j] = ^uint64(0)
+			}
+		}
+		vBig := new(big.Int)
+		for j := len(v) - 1; j >= 0; j-- {
+			vBig.Lsh(vBig, 64)
+			vBig.Or(vBig, new(big.Int).SetUint64(v[j]))
+		}
+		expected := new(big.Int).Mod(vBig, ORDER)
+
+		if ToNonCanonicalBigInt(ScalarFromUint640(v)).Cmp(expected) != 0 {
+			t.Fatalf("ScalarFromUint640: wrong reduction of %v", vBig)
+		}
+		if ToNonCanonicalBigInt(FromNonCanonicalBigInt(vBig)).Cmp(expected) != 0 {
+			t.Fatalf("FromNonCanonicalBigInt: wrong reduction of %v", vBig)
+		}
+		neg := new(big.Int).Neg(vBig)
+		if ToNonCanonicalBigInt(FromNonCanonicalBigInt(neg)).Cmp(new(big.Int).Mod(neg, ORDER)) != 0 {
+			t.Fatalf("FromNonCanonicalBigInt: wrong reduction of %v", neg)
+		}
+
+		e := gFp5.Sample()
+		eBig := new(big.Int)
+		for j := 4; j >= 0; j-- {
+			eBig.Lsh(eBig, 64)
+			eBig.Or(eBig, new(big.Int).SetUint64(e[j].ToCanonicalUint64()))
+		}
+		if ToNonCanonicalBigInt(FromGfp5(e)).Cmp(new(big.Int).Mod(eBig, ORDER)) != 0 {
+			t.Fatalf("FromGfp5: wrong reduction of %v", eBig)
+		}
+	}
+
+	if !FromNonCanonicalBigInt(new(big.Int)).Equals(ZERO) || !FromNonCanonicalBigInt(ORDER).Equals(ZERO) {
+		t.Fatalf("FromNonCanonicalBigInt: multiples of n should reduce to zero")
+	}
+}
+
+func TestNegInverseSquareExp(t *testing.T) {
+	if !ZERO.Neg().Equals(ZERO) || !ONE.Neg().Equals(NEG_ONE) || !ZERO.Inverse().Equals(ZERO) || !ONE.Inverse().Equals(ONE) {
+		t.Fatalf("Neg/Inverse: wrong result on a constant")
+	}
+
+	for i := 0; i < 20; i++ {
+		s := SampleScalar()
+		sBig := ToNonCanonicalBigInt(s)
+
+		if !s.Add(s.Neg()).Equals(ZERO) {
+			t.Fatalf("Neg: s + (-s) != 0")
+		}
+		if !s.Mul(s.Inverse()).Equals(ONE) {
+			t.Fatalf("Inverse: s * (1/s) != 1")
+		}
+		if !s.Square().Equals(s.Mul(s)) {
+			t.Fatalf("Square: s^2 != s*s")
+		}
+
+		e := ECgFp5Scalar{rand.Uint64(), rand.Uint64(), rand.Uint64(), rand.Uint64(), rand.Uint64()} //nolint:gosec
+		expected := new(big.Int).Exp(sBig, ToNonCanonicalBigInt(e), ORDER)
+		if ToNonCanonicalBigInt(s.Exp(e)).Cmp(expected) != 0 {
+			t.Fatalf("Exp: wrong result")
+		}
+	}
+	if !TWO.Exp(ZERO).Equals(ONE) || !TWO.Exp(ECgFp5Scalar{10, 0, 0, 0, 0}).Equals(ECgFp5Scalar{1024, 0, 0, 0, 0}) {
+		t.Fatalf("Exp: wrong result for small exponents")
+	}
+}
+
+func TestBatchInverse(t *testing.T) {
+	s := make([]ECgFp5Scalar, 10)
+	for i := range s {
+		s[i] = SampleScalar()
+	}
+	s[0] = ZERO
+	s[5] = ZERO
+
+	inv := BatchInverse(s)
+	for i := range s {
+		if !inv[i].Equals(s[i].Inverse()) {
+			t.Fatalf("BatchInverse: wrong inverse at index %d", i)
+		}
+	}
+	if BatchInverse(nil) != nil {
+		t.Fatalf("BatchInverse: expected nil for empty input")
+	}
+}
+
+func TestHashToScalar(t *testing.T) {
+	input := []g.GoldilocksField{1, 2, 3}
+	s := HashToScalar(input)
+	if !s.IsCanonical() {
+		t.Fatalf("HashToScalar: non-canonical output")
+	}
+	expected := ECgFp5Scalar{
+		15757643307368947530,
+		15811097535283680348,
+		11289606618763585545,
+		13230951794471192675,
+		6058954689502881172,
+	}
+	if !s.Equals(expected) {
+		t.Fatalf("HashToScalar: expected %v, got %v", expected, s)
+	}
+
+	h := p2.HashNToMNoPad(input, 10)
+	hBig := new(big.Int)
+	for i := len(h) - 1; i >= 0; i-- {
+		hBig.Lsh(hBig, 64)
+		hBig.Or(hBig, new(big.Int).SetUint64(h[i].ToCanonicalUint64()))
+	}
+	if ToNonCanonicalBigInt(s).Cmp(hBig.Mod(hBig, ORDER)) != 0 {
+		t.Fatalf("HashToScalar: output is not the reduced squeeze")
+	}
+	if HashToScalar(input[:2]).Equals(s) {
+		t.Fatalf("HashToScalar: distinct inputs should yield distinct scalars")
+	}
+}
+
+func BenchmarkScalarInverse(b *testing.B) {
+	s := SampleScalar()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s = s.Inverse()
+	}
+}
+
+func TestScalarFromBytes(t *testing.T) {
+	s := SampleScalar()
+	decoded, err := ScalarFromCanonicalBytes(s.ToLittleEndianBytes())
+	if err != nil || !decoded.Equals(s) {
+		t.Fatalf("ScalarFromCanonicalBytes: failed to decode a canonical scalar")
+	}
+
+	nMinusOne, _ := N.SubInner(ONE)
+	if _, err := ScalarFromCanonicalBytes(nMinusOne.ToLittleEndianBytes()); err != nil {
+		t.Fatalf("ScalarFromCanonicalBytes: n-1 should be accepted: %v", err)
+	}
+	for _, b := range [][]byte{
+		N.ToLittleEndianBytes(),
+		s.AddInner(N).ToLittleEndianBytes(),
+		s.ToLittleEndianBytes()[:39],
+		append(s.ToLittleEndianBytes(), 0),
+		nil,
+	} {
+		if _, err := ScalarFromCanonicalBytes(b); err == nil {
+			t.Fatalf("ScalarFromCanonicalBytes: expected an error for %x", b)
+		}
+	}
+
+	if !ScalarFromBytesReduced(s.AddInner(N).ToLittleEndianBytes()).Equals(s) {
+		t.Fatalf("ScalarFromBytesReduced: s + n should reduce to s")
+	}
+	if !ScalarFromBytesReduced(nil).Equals(ZERO) || !ScalarFromBytesReduced([]byte{2}).Equals(TWO) {
+		t.Fatalf("ScalarFromBytesReduced: wrong value for short inputs")
+	}
+}
+
+func TestSampleScalarFrom(t *testing.T) {
+	// All-zero blocks are skipped; the next block is reduced.
+	input := make([]byte, 160)
+	input[80] = 2
+	s, err := SampleScalarFrom(bytes.NewReader(input))
+	if err != nil || !s.Equals(TWO) {
+		t.Fatalf("SampleScalarFrom should skip zero and reduce the next 80 bytes, got %v (%v)", s, err)
+	}
+	if _, err := SampleScalarFrom(bytes.NewReader(make([]byte, 79))); err == nil {
+		t.Fatalf("SampleScalarFrom should report a failing reader")
+	}
+	s, err = SampleScalarFrom(nil)
+	if err != nil || !s.IsCanonical() || s.Equals(ZERO) {
+		t.Fatalf("SampleScalarFrom(nil) should return a canonical non-zero scalar")
+	}
+}
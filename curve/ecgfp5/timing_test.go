@@ -0,0 +1,207 @@
+//go:build timing
+
+package ecgfp5
+
+// Statistical timing leak detection, in the style of dudect (Reparaz,
+// Balasch and Verbauwhede, "Dude, is my code constant time?", DATE 2017).
+// Each operation is timed on two classes of inputs, a fixed one (usually
+// a special value such as zero) and random ones, interleaved at random.
+// A Welch t-test then checks whether the two timing distributions differ.
+//
+// These tests are slow and sensitive to system noise, so they only run
+// with the "timing" build tag:
+//
+//	go test -tags timing -run TestTiming ./curve/ecgfp5
+//
+// Use -timing.measurements to change the number of measurements per
+// operation; leaks that are too small to be detected with the default
+// count may show up with more measurements.
+
+import (
+	"flag"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"testing"
+	"time"
+
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	. "github.com/elliottech/poseidon_crypto/int"
+)
+
+var timingMeasurements = flag.Int("timing.measurements", 20000, "number of timing measurements per operation")
+
+// Absolute t value above which the distributions are considered to
+// differ; dudect reports values above 10 as definitely not constant-time.
+const timingThreshold = 10.0
+
+// Online mean and variance (Welford) of the timings of both classes.
+type welchTest struct {
+	n, mean, m2 [2]float64
+}
+
+func (w *welchTest) push(x float64, class int) {
+	w.n[class]++
+	d := x - w.mean[class]
+	w.mean[class] += d / w.n[class]
+	w.m2[class] += d * (x - w.mean[class])
+}
+
+func (w *welchTest) t() float64 {
+	if w.n[0] < 2 || w.n[1] < 2 {
+		return 0
+	}
+	v0 := w.m2[0] / (w.n[0] - 1)
+	v1 := w.m2[1] / (w.n[1] - 1)
+	den := math.Sqrt(v0/w.n[0] + v1/w.n[1])
+	if den == 0 {
+		return 0
+	}
+	return (w.mean[0] - w.mean[1]) / den
+}
+
+// Time op on inputs of both classes, and return the largest absolute t
+// value. Each measurement runs op reps times on the same input, so that
+// fast operations are well above the timer resolution. As in dudect, the
+// test is also run on measurements cropped at several percentiles, to
+// remove the outliers caused by interrupts and scheduling.
+func measureTiming[T any](n, reps int, gen func(class int) T, op func(T)) float64 {
+	classes := make([]int, n)
+	inputs := make([]T, n)
+	for i := range inputs {
+		classes[i] = rand.IntN(2) //nolint:gosec
+		inputs[i] = gen(classes[i])
+	}
+	times := make([]float64, n)
+
+	runtime.GC()
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+	for i := range inputs {
+		start := time.Now()
+		for j := 0; j < reps; j++ {
+			op(inputs[i])
+		}
+		times[i] = float64(time.Since(start).Nanoseconds())
+	}
+
+	sorted := append([]float64(nil), times...)
+	sort.Float64s(sorted)
+	maxT := 0.0
+	for _, pct := range []float64{1, 0.99, 0.9, 0.75, 0.5} {
+		limit := sorted[int(pct*float64(n-1))]
+		var w welchTest
+		for i, x := range times {
+			if x <= limit {
+				w.push(x, classes[i])
+			}
+		}
+		maxT = math.Max(maxT, math.Abs(w.t()))
+	}
+	return maxT
+}
+
+func checkTiming[T any](t *testing.T, name string, reps int, gen func(class int) T, op func(T)) {
+	t.Helper()
+	tv := measureTiming(*timingMeasurements, reps, gen, op)
+	t.Logf("%s: max |t| = %.2f", name, tv)
+	if tv > timingThreshold {
+		t.Errorf("%s: timing depends on the input class (|t| = %.2f > %.2f)", name, tv, timingThreshold)
+	}
+}
+
+func TestTimingMul(t *testing.T) {
+	p := GENERATOR_ECgFp5Point.Mul(SampleScalar())
+	var sink ECgFp5Point
+	checkTiming(t, "ECgFp5Point.Mul", 1,
+		func(class int) ECgFp5Scalar {
+			if class == 0 {
+				return ZERO
+			}
+			return SampleScalar()
+		},
+		func(s ECgFp5Scalar) { sink = p.Mul(s) },
+	)
+	_ = sink
+}
+
+func TestTimingLookup(t *testing.T) {
+	win := GENERATOR_ECgFp5Point.MakeWindowAffine()
+	var sink AffinePoint
+	checkTiming(t, "Lookup", 100,
+		func(class int) int32 {
+			if class == 0 {
+				return 0
+			}
+			return rand.Int32N(2*WIN_SIZE+1) - WIN_SIZE //nolint:gosec
+		},
+		func(k int32) { sink = Lookup(win, k) },
+	)
+	_ = sink
+}
+
+func TestTimingInverseOrZero(t *testing.T) {
+	var sink gFp5.Element
+	checkTiming(t, "gFp5.InverseOrZero", 10,
+		func(class int) gFp5.Element {
+			if class == 0 {
+				return gFp5.FP5_ZERO
+			}
+			return gFp5.Sample()
+		},
+		func(a gFp5.Element) { sink = gFp5.InverseOrZero(a) },
+	)
+	_ = sink
+}
+
+func TestTimingCanonicalSqrt(t *testing.T) {
+	// The fixed class is a single square, whose root always has the same
+	// sign; random squares have roots of either sign.
+	fixed := gFp5.Square(gFp5.Sample())
+	var sink gFp5.Element
+	checkTiming(t, "gFp5.CanonicalSqrt", 10,
+		func(class int) gFp5.Element {
+			if class == 0 {
+				return fixed
+			}
+			return gFp5.Square(gFp5.Sample())
+		},
+		func(a gFp5.Element) { sink, _ = gFp5.CanonicalSqrt(a) },
+	)
+	_ = sink
+}
+
+func TestTimingScalarSub(t *testing.T) {
+	var sink ECgFp5Scalar
+	checkTiming(t, "ECgFp5Scalar.Sub", 100,
+		func(class int) [2]ECgFp5Scalar {
+			if class == 0 {
+				return [2]ECgFp5Scalar{ZERO, ZERO}
+			}
+			return [2]ECgFp5Scalar{SampleScalar(), SampleScalar()}
+		},
+		func(v [2]ECgFp5Scalar) { sink = v[0].Sub(v[1]) },
+	)
+	_ = sink
+}
+
+// Check that the harness does detect an obvious leak: lattice basis
+// reduction is vartime, and immediate for k = 1.
+func TestTimingHarnessDetectsLeak(t *testing.T) {
+	var sink Signed161
+	tv := measureTiming(*timingMeasurements/10, 1,
+		func(class int) ECgFp5Scalar {
+			if class == 0 {
+				return ONE
+			}
+			return SampleScalar()
+		},
+		func(k ECgFp5Scalar) { sink, _ = k.Lagrange() },
+	)
+	_ = sink
+	t.Logf("Lagrange: max |t| = %.2f", tv)
+	if tv <= timingThreshold {
+		t.Fatalf("timing harness failed to detect a vartime operation (|t| = %.2f)", tv)
+	}
+}
@@ -1,6 +1,8 @@
 package ecgfp5
 
 import (
+	"errors"
+
 	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
 	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
 )
@@ -53,6 +55,62 @@ func (p WeierstrassPoint) Equals(q WeierstrassPoint) bool {
 	return gFp5.Equals(p.X, q.X) && gFp5.Equals(p.Y, q.Y)
 }
 
+// NewWeierstrassPoint returns the affine point (x, y) of the short
+// Weierstrass model, after checking that it is on the curve and in the
+// prime-order group (see PointFromWeierstrass()).
+func NewWeierstrassPoint(x, y gFp5.Element) (WeierstrassPoint, error) {
+	w := WeierstrassPoint{X: x, Y: y, IsInf: false}
+	if _, err := PointFromWeierstrass(w); err != nil {
+		return NEUTRAL_WEIERSTRASS, err
+	}
+	return w, nil
+}
+
+// IsOnCurve checks that the point fulfills the curve equation
+// Y^2 = X^3 + A*X + B. The point at infinity is on the curve. Note that
+// the curve has order 2*n; use PointFromWeierstrass() to also check that
+// the point is in the prime-order group.
+func (p WeierstrassPoint) IsOnCurve() bool {
+	if p.IsInf {
+		return true
+	}
+	return gFp5.Equals(gFp5.Square(p.Y), sswuCurveEquation(p.X))
+}
+
+func (p WeierstrassPoint) Neg() WeierstrassPoint {
+	if p.IsInf {
+		return p
+	}
+	return WeierstrassPoint{X: p.X, Y: gFp5.Neg(p.Y), IsInf: false}
+}
+
+func (p WeierstrassPoint) Sub(q WeierstrassPoint) WeierstrassPoint {
+	return p.Add(q.Neg())
+}
+
+// ToBytes returns the 40-byte encoding of this point, which is the same
+// as the one of the corresponding ECgFp5Point.
+func (p WeierstrassPoint) ToBytes() []byte {
+	if p.IsInf {
+		return gFp5.FP5_ZERO.ToLittleEndianBytes()
+	}
+	return p.Encode().ToLittleEndianBytes()
+}
+
+// WeierstrassPointFromBytes decodes a point from its 40-byte encoding,
+// with the same rules as PointFromBytes().
+func WeierstrassPointFromBytes(b []byte) (WeierstrassPoint, error) {
+	w, err := fp5FromCanonicalBytes(b)
+	if err != nil {
+		return NEUTRAL_WEIERSTRASS, err
+	}
+	p, ok := DecodeFp5AsWeierstrass(w)
+	if !ok {
+		return NEUTRAL_WEIERSTRASS, errors.New("invalid point encoding")
+	}
+	return p, nil
+}
+
 func (p WeierstrassPoint) Encode() gFp5.Element {
 	return gFp5.Div(p.Y, gFp5.Sub(gFp5.Div(A_ECgFp5Point, gFp5.FromUint64(3)), p.X))
 }
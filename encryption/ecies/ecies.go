@@ -0,0 +1,171 @@
+// Package ecies implements ECDH key agreement and hybrid public-key
+// encryption over ECgFp5, so that payloads can be encrypted to Schnorr
+// public keys without a second curve.
+//
+// ECDH multiplies the peer public key by the secret scalar, in constant
+// time, and hashes the encoded shared point with Poseidon2:
+//
+//	key = HashNToMNoPad(ECDH_TAG || Encode(sk * PK), 4)
+//
+// as a 32-byte little-endian string.
+//
+// Seal encrypts to a public key PK with an ephemeral key pair (esk, EPK):
+//
+//	key = HashNToMNoPad(ECIES_TAG || Encode(esk * PK) || EPK || PK, 4)
+//	ciphertext = Version || EPK || AES-256-GCM(key, nonce = 0, plaintext,
+//	                                           Version || EPK || aad)
+//
+// with EPK and PK as encoded points (40 bytes in the output). Each key is
+// used for a single message, so a fixed nonce is safe. The output is
+// Overhead bytes longer than the plaintext.
+package ecies
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+// Domain separation tags, as ASCII strings read as little-endian 64-bit
+// integers.
+const (
+	// "ecdh-key": ECDH shared keys.
+	ECDH_TAG = g.GoldilocksField(0x79656b2d68646365)
+	// "ecies-v1": encryption keys of Seal.
+	ECIES_TAG = g.GoldilocksField(0x31762d7365696365)
+)
+
+// Version of the wire format of Seal.
+const Version byte = 0x01
+
+// Length of a shared key, in bytes.
+const KeySize = 32
+
+// Lengths of the wire format, in bytes: version (1) || ephemeral public
+// key (40) || ciphertext || GCM tag (16).
+const (
+	headerSize = 1 + 40
+	tagSize    = 16
+	Overhead   = headerSize + tagSize
+)
+
+// ECDH computes the key shared between the owner of sk and the owner of
+// pk. The secret scalar must be canonical and non-zero, and pk must decode
+// to a group element other than the neutral.
+func ECDH(sk curve.ECgFp5Scalar, pk gFp5.Element) ([]byte, error) {
+	shared, _, err := sharedPoint(sk, pk)
+	if err != nil {
+		return nil, err
+	}
+	return kdf(ECDH_TAG, shared), nil
+}
+
+// Seal encrypts the plaintext to the public key pk, with an ephemeral key
+// sampled from rand (or crypto/rand if rand is nil). The additional data
+// aad is authenticated but not encrypted, and must be provided to Open.
+func Seal(rand io.Reader, pk gFp5.Element, plaintext, aad []byte) ([]byte, error) {
+	esk, err := curve.SampleScalarFrom(rand)
+	if err != nil {
+		return nil, err
+	}
+	// The canonical encoding of pk is hashed, as in Open.
+	shared, pkEnc, err := sharedPoint(esk, pk)
+	if err != nil {
+		return nil, err
+	}
+	epk := curve.MulGen(esk).Encode()
+
+	aead, err := newAEAD(kdf(ECIES_TAG, shared, epk, pkEnc))
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, headerSize+len(plaintext)+tagSize)
+	header = append(header, Version)
+	header = append(header, epk.ToLittleEndianBytes()...)
+	return aead.Seal(header, make([]byte, aead.NonceSize()), plaintext, additionalData(header, aad)), nil
+}
+
+// Open decrypts a ciphertext produced by Seal for the public key of sk,
+// with the same additional data.
+func Open(sk curve.ECgFp5Scalar, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < Overhead {
+		return nil, errors.New("ciphertext too short")
+	}
+	if ciphertext[0] != Version {
+		return nil, fmt.Errorf("unknown ciphertext version 0x%02x", ciphertext[0])
+	}
+	header := ciphertext[:headerSize]
+	epk, err := curve.PointFromBytes(header[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+
+	shared, _, err := sharedPoint(sk, epk.Encode())
+	if err != nil {
+		return nil, err
+	}
+	pk := curve.MulGen(sk).Encode()
+	aead, err := newAEAD(kdf(ECIES_TAG, shared, epk.Encode(), pk))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, make([]byte, aead.NonceSize()), ciphertext[headerSize:], additionalData(header, aad))
+	if err != nil {
+		return nil, errors.New("decryption failed")
+	}
+	return plaintext, nil
+}
+
+// Compute sk * pk, in constant time with respect to sk, along with the
+// canonical encoding of pk (Decode() also accepts non-canonical limbs).
+func sharedPoint(sk curve.ECgFp5Scalar, pk gFp5.Element) (gFp5.Element, gFp5.Element, error) {
+	if !sk.IsCanonical() {
+		return gFp5.FP5_ZERO, gFp5.FP5_ZERO, errors.New("secret key is not canonical")
+	}
+	if sk.Equals(curve.ZERO) {
+		return gFp5.FP5_ZERO, gFp5.FP5_ZERO, errors.New("secret key is zero")
+	}
+	point, ok := curve.Decode(pk)
+	if !ok {
+		return gFp5.FP5_ZERO, gFp5.FP5_ZERO, errors.New("invalid public key encoding")
+	}
+	if point.IsNeutral() {
+		return gFp5.FP5_ZERO, gFp5.FP5_ZERO, errors.New("public key is the neutral element")
+	}
+	// The group has prime order, so the product is not the neutral.
+	return point.Mul(sk).Encode(), point.Encode(), nil
+}
+
+// Hash the tag and the encoded points to a 32-byte key.
+func kdf(tag g.GoldilocksField, points ...gFp5.Element) []byte {
+	input := make([]g.GoldilocksField, 0, 1+5*len(points))
+	input = append(input, tag)
+	for _, p := range points {
+		input = append(input, p[:]...)
+	}
+	var key p2.HashOut
+	copy(key[:], p2.HashNToMNoPad(input, 4))
+	return key.ToLittleEndianBytes()
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// The header has a fixed length, so header || aad is unambiguous.
+func additionalData(header, aad []byte) []byte {
+	res := make([]byte, 0, headerSize+len(aad))
+	res = append(res, header[:headerSize]...)
+	return append(res, aad...)
+}
@@ -0,0 +1,178 @@
+package ecies
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+var (
+	testSk  = curve.ECgFp5Scalar{12235002942052073545, 1175977464658719998, 8536934969147463310, 6524687619313720391, 2922072024880609112}
+	testSk2 = curve.ECgFp5Scalar{14609471659974493146, 15558617123161593410, 853367204868339037, 17594253198278631904, 368396584122947478}
+)
+
+// Known-answer vectors, to be matched by other implementations.
+func TestVectors(t *testing.T) {
+	key, err := ECDH(testSk, curve.MulGen(testSk2).Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(key); got != "6fca7ca5d7c0070cfbe5cec6517975ede14164cc684b73c197447b2c1aafbccd" {
+		t.Fatalf("wrong ECDH key %s", got)
+	}
+
+	// The ephemeral key is read from a fixed reader.
+	rand := bytes.NewReader(bytes.Repeat([]byte{7}, 80))
+	ct, err := Seal(rand, curve.MulGen(testSk).Encode(), []byte("order payload"), []byte("aad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "01b7e761a3c8c1b067766c09cf323eb07f2dc5a02558e26ce18fcc5ba593f41a52e9831ccd9eaac88f0e4c9df50d3d085413371dbc91ecd6e62790245736339d8e5c1bfe49dc"
+	if got := hex.EncodeToString(ct); got != expected {
+		t.Fatalf("wrong ciphertext %s", got)
+	}
+	pt, err := Open(testSk, ct, []byte("aad"))
+	if err != nil || string(pt) != "order payload" {
+		t.Fatalf("failed to open the vector: %v", err)
+	}
+}
+
+func TestECDH(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		a, b := curve.SampleScalar(), curve.SampleScalar()
+		ab, err := ECDH(a, curve.MulGen(b).Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ba, err := ECDH(b, curve.MulGen(a).Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ab) != KeySize || !bytes.Equal(ab, ba) {
+			t.Fatalf("ECDH keys differ")
+		}
+	}
+
+	pk := curve.MulGen(testSk2).Encode()
+	if _, err := ECDH(curve.ZERO, pk); err == nil {
+		t.Fatalf("ECDH should reject a zero secret key")
+	}
+	if _, err := ECDH(curve.ECgFp5Scalar{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}, pk); err == nil {
+		t.Fatalf("ECDH should reject a non-canonical secret key")
+	}
+	if _, err := ECDH(testSk, gFp5.FP5_ZERO); err == nil {
+		t.Fatalf("ECDH should reject the neutral")
+	}
+	if _, err := ECDH(testSk, gFp5.Element{1, 0, 0, 0, 0}); err == nil {
+		t.Fatalf("ECDH should reject invalid encodings")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := curve.MulGen(sk).Encode()
+	aad := []byte("account 42")
+
+	for _, size := range []int{0, 1, 16, 1000} {
+		plaintext := bytes.Repeat([]byte{0xab}, size)
+		ct, err := Seal(nil, pk, plaintext, aad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != size+Overhead || ct[0] != Version {
+			t.Fatalf("unexpected ciphertext layout for %d bytes", size)
+		}
+		pt, err := Open(sk, ct, aad)
+		if err != nil || !bytes.Equal(pt, plaintext) {
+			t.Fatalf("failed to open %d bytes: %v", size, err)
+		}
+	}
+
+	// Fresh ephemeral keys: sealing twice yields distinct ciphertexts.
+	ct1, _ := Seal(nil, pk, []byte("m"), nil)
+	ct2, _ := Seal(nil, pk, []byte("m"), nil)
+	if bytes.Equal(ct1, ct2) {
+		t.Fatalf("ciphertexts should be randomized")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := curve.MulGen(sk).Encode()
+	ct, err := Seal(nil, pk, []byte("order payload"), []byte("aad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(sk, ct, []byte("other")); err == nil {
+		t.Fatalf("Open should reject other additional data")
+	}
+	if _, err := Open(curve.SampleScalar(), ct, []byte("aad")); err == nil {
+		t.Fatalf("Open should reject another key")
+	}
+	if _, err := Open(sk, ct[:Overhead-1], []byte("aad")); err == nil {
+		t.Fatalf("Open should reject short ciphertexts")
+	}
+	for i := range ct {
+		tampered := bytes.Clone(ct)
+		tampered[i] ^= 1
+		if _, err := Open(sk, tampered, []byte("aad")); err == nil {
+			t.Fatalf("Open should reject a ciphertext tampered at byte %d", i)
+		}
+	}
+
+	if _, err := Seal(nil, gFp5.FP5_ZERO, []byte("m"), nil); err == nil {
+		t.Fatalf("Seal should reject the neutral")
+	}
+	if _, err := Seal(bytes.NewReader(nil), pk, []byte("m"), nil); err == nil {
+		t.Fatalf("Seal should report a failing reader")
+	}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	pk := curve.MulGen(testSk).Encode()
+	plaintext := make([]byte, 256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Seal(nil, pk, plaintext, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestNonCanonicalPublicKey(t *testing.T) {
+	// A valid encoding with a small first limb, which can be written
+	// non-canonically as limb + ORDER.
+	pk := gFp5.Element{4, 0, 0, 0, 0}
+	if _, ok := curve.Decode(pk); !ok {
+		t.Fatalf("test public key should decode")
+	}
+	nonCanonical := pk
+	nonCanonical[0] += g.GoldilocksField(g.ORDER)
+
+	// Sealing to either encoding yields the same ciphertext, which the
+	// owner of the key can open.
+	seal := func(pk gFp5.Element) []byte {
+		ct, err := Seal(bytes.NewReader(bytes.Repeat([]byte{7}, 80)), pk, []byte("m"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ct
+	}
+	if !bytes.Equal(seal(pk), seal(nonCanonical)) {
+		t.Fatalf("Seal should hash the canonical encoding of the public key")
+	}
+
+	a, err := ECDH(testSk, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ECDH(testSk, nonCanonical)
+	if err != nil || !bytes.Equal(a, b) {
+		t.Fatalf("ECDH should not depend on the public key encoding")
+	}
+}
@@ -0,0 +1,166 @@
+// Package elgamal implements exponential ElGamal encryption over ECgFp5,
+// for confidential amounts which can be added without being decrypted.
+//
+// An amount m is encrypted to a public key PK = sk*G with randomness r as:
+//
+//	C1 = r*G
+//	C2 = m*G + r*PK
+//
+// Ciphertexts are additively homomorphic: the sum of encryptions of m1 and
+// m2 (under the same key) is an encryption of m1 + m2. Decryption
+// recovers M = C2 - sk*C1 = m*G, and then m with a baby-step giant-step
+// search over a bounded range (see Table), so only small amounts (e.g.
+// up to 2^32) can be decrypted.
+//
+// Public and secret keys are Schnorr keys: PK = SchnorrPkFromSk(sk).
+package elgamal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+// Length of an encoded ciphertext, in bytes.
+const CiphertextSize = 40 + 40
+
+// Ciphertext is an ElGamal ciphertext (C1, C2).
+type Ciphertext struct {
+	C1 curve.ECgFp5Point
+	C2 curve.ECgFp5Point
+}
+
+// Encryption of zero with no randomness, i.e. the neutral of ciphertext
+// addition.
+var ZERO_CIPHERTEXT = Ciphertext{
+	C1: curve.NEUTRAL_ECgFp5Point,
+	C2: curve.NEUTRAL_ECgFp5Point,
+}
+
+// Encrypt encrypts the amount m to the public key pk, with randomness
+// from rand (or crypto/rand if rand is nil).
+func Encrypt(rand io.Reader, pk gFp5.Element, m uint64) (Ciphertext, error) {
+	pkPoint, err := decodePublicKey(pk)
+	if err != nil {
+		return ZERO_CIPHERTEXT, err
+	}
+	r, err := curve.SampleScalarFrom(rand)
+	if err != nil {
+		return ZERO_CIPHERTEXT, err
+	}
+	return encrypt(pkPoint, amountScalar(m), r), nil
+}
+
+// EncryptWithRandomness encrypts the amount m to the public key pk, with
+// the provided randomness r, which must be a secret, uniformly random
+// scalar. This is meant for proofs about the ciphertext; otherwise, use
+// Encrypt.
+func EncryptWithRandomness(pk gFp5.Element, m uint64, r curve.ECgFp5Scalar) (Ciphertext, error) {
+	pkPoint, err := decodePublicKey(pk)
+	if err != nil {
+		return ZERO_CIPHERTEXT, err
+	}
+	if !r.IsCanonical() {
+		return ZERO_CIPHERTEXT, errors.New("randomness is not canonical")
+	}
+	return encrypt(pkPoint, amountScalar(m), r), nil
+}
+
+func encrypt(pk curve.ECgFp5Point, m, r curve.ECgFp5Scalar) Ciphertext {
+	return Ciphertext{
+		C1: curve.MulGen(r),
+		C2: curve.MulGen(m).Add(pk.Mul(r)),
+	}
+}
+
+// Decrypt recovers the amount of the ciphertext, which must be in the
+// range of the table. The secret key is used in constant time, but the
+// search takes time depending on the amount.
+func Decrypt(sk curve.ECgFp5Scalar, ct Ciphertext, table *Table) (uint64, error) {
+	if !sk.IsCanonical() {
+		return 0, errors.New("secret key is not canonical")
+	}
+	return table.Solve(ct.C2.Sub(ct.C1.Mul(sk)))
+}
+
+// Add returns an encryption of the sum of both amounts.
+func (ct Ciphertext) Add(rhs Ciphertext) Ciphertext {
+	return Ciphertext{
+		C1: ct.C1.Add(rhs.C1),
+		C2: ct.C2.Add(rhs.C2),
+	}
+}
+
+// Sub returns an encryption of the difference of both amounts. The result
+// can only be decrypted if it is not negative.
+func (ct Ciphertext) Sub(rhs Ciphertext) Ciphertext {
+	return Ciphertext{
+		C1: ct.C1.Sub(rhs.C1),
+		C2: ct.C2.Sub(rhs.C2),
+	}
+}
+
+// AddAmount returns an encryption of the amount plus the public amount m.
+func (ct Ciphertext) AddAmount(m uint64) Ciphertext {
+	return Ciphertext{
+		C1: ct.C1,
+		C2: ct.C2.Add(curve.MulGen(amountScalar(m))),
+	}
+}
+
+// Rerandomize returns a fresh encryption of the same amount, which cannot
+// be linked to the original ciphertext, by adding an encryption of zero.
+func (ct Ciphertext) Rerandomize(rand io.Reader, pk gFp5.Element) (Ciphertext, error) {
+	zero, err := Encrypt(rand, pk, 0)
+	if err != nil {
+		return ZERO_CIPHERTEXT, err
+	}
+	return ct.Add(zero), nil
+}
+
+// Equals reports whether both ciphertexts are identical.
+func (ct Ciphertext) Equals(rhs Ciphertext) bool {
+	return ct.C1.Equals(rhs.C1) && ct.C2.Equals(rhs.C2)
+}
+
+// ToBytes returns Encode(C1) || Encode(C2), little-endian.
+func (ct Ciphertext) ToBytes() []byte {
+	res := make([]byte, 0, CiphertextSize)
+	res = append(res, ct.C1.Encode().ToLittleEndianBytes()...)
+	return append(res, ct.C2.Encode().ToLittleEndianBytes()...)
+}
+
+// CiphertextFromBytes decodes a ciphertext; both points must have a
+// canonical encoding.
+func CiphertextFromBytes(b []byte) (Ciphertext, error) {
+	if len(b) != CiphertextSize {
+		return ZERO_CIPHERTEXT, fmt.Errorf("invalid ciphertext length, must be %d bytes", CiphertextSize)
+	}
+	c1, err := curve.PointFromBytes(b[:40])
+	if err != nil {
+		return ZERO_CIPHERTEXT, fmt.Errorf("invalid C1: %w", err)
+	}
+	c2, err := curve.PointFromBytes(b[40:])
+	if err != nil {
+		return ZERO_CIPHERTEXT, fmt.Errorf("invalid C2: %w", err)
+	}
+	return Ciphertext{C1: c1, C2: c2}, nil
+}
+
+func decodePublicKey(pk gFp5.Element) (curve.ECgFp5Point, error) {
+	point, ok := curve.Decode(pk)
+	if !ok {
+		return curve.NEUTRAL_ECgFp5Point, errors.New("invalid public key encoding")
+	}
+	if point.IsNeutral() {
+		return curve.NEUTRAL_ECgFp5Point, errors.New("public key is the neutral element")
+	}
+	return point, nil
+}
+
+func amountScalar(m uint64) curve.ECgFp5Scalar {
+	return curve.ECgFp5Scalar{m, 0, 0, 0, 0}
+}
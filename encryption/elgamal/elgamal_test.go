@@ -0,0 +1,200 @@
+package elgamal
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+)
+
+var (
+	testTableOnce sync.Once
+	testTable     *Table
+)
+
+// A small table, for amounts in [0, 2^16).
+func smallTable(t testing.TB) *Table {
+	testTableOnce.Do(func() {
+		var err error
+		testTable, err = NewTable(8, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	return testTable
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	table := smallTable(t)
+	sk := curve.SampleScalar()
+	pk := curve.MulGen(sk).Encode()
+
+	for _, m := range []uint64{0, 1, 2, 255, 256, 257, 12345, table.MaxAmount()} {
+		ct, err := Encrypt(nil, pk, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Decrypt(sk, ct, table)
+		if err != nil || got != m {
+			t.Fatalf("Decrypt(Encrypt(%d)) = %d, %v", m, got, err)
+		}
+	}
+
+	ct, _ := Encrypt(nil, pk, table.MaxAmount()+1)
+	if _, err := Decrypt(sk, ct, table); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("expected ErrOutOfRange, got %v", err)
+	}
+	ct, _ = Encrypt(nil, pk, 42)
+	if _, err := Decrypt(curve.SampleScalar(), ct, table); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("decryption with another key should fail, got %v", err)
+	}
+}
+
+func TestHomomorphism(t *testing.T) {
+	table := smallTable(t)
+	sk := curve.SampleScalar()
+	pk := curve.MulGen(sk).Encode()
+
+	a, _ := Encrypt(nil, pk, 1000)
+	b, _ := Encrypt(nil, pk, 234)
+	check := func(ct Ciphertext, expected uint64) {
+		t.Helper()
+		got, err := Decrypt(sk, ct, table)
+		if err != nil || got != expected {
+			t.Fatalf("expected %d, got %d (%v)", expected, got, err)
+		}
+	}
+
+	check(a.Add(b), 1234)
+	check(a.Sub(b), 766)
+	check(a.AddAmount(66), 1066)
+	check(ZERO_CIPHERTEXT.Add(b), 234)
+
+	r, err := a.Rerandomize(nil, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Equals(a) {
+		t.Fatalf("Rerandomize should change the ciphertext")
+	}
+	check(r, 1000)
+
+	if _, err := Decrypt(sk, b.Sub(a), table); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("negative amounts should be out of range, got %v", err)
+	}
+}
+
+func TestEncryptWithRandomness(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := curve.MulGen(sk).Encode()
+	r := curve.SampleScalar()
+
+	ct, err := EncryptWithRandomness(pk, 7, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ct.C1.Equals(curve.MulGen(r)) {
+		t.Fatalf("C1 should be r*G")
+	}
+	if !ct.C2.Equals(curve.MulGen(amountScalar(7)).Add(curve.MulGen(sk).Mul(r))) {
+		t.Fatalf("C2 should be m*G + r*PK")
+	}
+	if _, err := EncryptWithRandomness(pk, 7, curve.ECgFp5Scalar{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}); err == nil {
+		t.Fatalf("non-canonical randomness should be rejected")
+	}
+	if _, err := Encrypt(nil, curve.NEUTRAL_ECgFp5Point.Encode(), 7); err == nil {
+		t.Fatalf("the neutral should be rejected as a public key")
+	}
+}
+
+func TestCiphertextBytes(t *testing.T) {
+	pk := curve.MulGen(curve.SampleScalar()).Encode()
+	ct, _ := Encrypt(nil, pk, 99)
+
+	b := ct.ToBytes()
+	if len(b) != CiphertextSize {
+		t.Fatalf("expected %d bytes, got %d", CiphertextSize, len(b))
+	}
+	decoded, err := CiphertextFromBytes(b)
+	if err != nil || !decoded.Equals(ct) {
+		t.Fatalf("ciphertext does not round-trip: %v", err)
+	}
+	if _, err := CiphertextFromBytes(b[:79]); err == nil {
+		t.Fatalf("short inputs should be rejected")
+	}
+	decoded, err = CiphertextFromBytes(ZERO_CIPHERTEXT.ToBytes())
+	if err != nil || !decoded.Equals(ZERO_CIPHERTEXT) {
+		t.Fatalf("the zero ciphertext does not round-trip: %v", err)
+	}
+}
+
+func TestTableSerialization(t *testing.T) {
+	table := smallTable(t)
+	b := table.Bytes()
+	if len(b) != 3+8*256 {
+		t.Fatalf("unexpected table length %d", len(b))
+	}
+
+	path := filepath.Join(t.TempDir(), "table.bin")
+	if err := table.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.MaxAmount() != table.MaxAmount() {
+		t.Fatalf("loaded table has another range")
+	}
+	for _, m := range []uint64{0, 3, 4000, table.MaxAmount()} {
+		got, err := loaded.Solve(curve.MulGen(amountScalar(m)))
+		if err != nil || got != m {
+			t.Fatalf("loaded table: Solve(%d) = %d, %v", m, got, err)
+		}
+	}
+
+	// A corrupted table may fail to decrypt, but never returns a wrong
+	// amount.
+	corrupted := append([]byte(nil), b...)
+	copy(corrupted[3+8*5:], corrupted[3+8*9:3+8*10])
+	bad, err := TableFromBytes(corrupted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []uint64{5, 9, 256 + 5, 256 + 9} {
+		if got, err := bad.Solve(curve.MulGen(amountScalar(m))); err == nil && got != m {
+			t.Fatalf("corrupted table: Solve(%d) = %d", m, got)
+		}
+	}
+
+	if _, err := TableFromBytes(b[:len(b)-1]); err == nil {
+		t.Fatalf("truncated tables should be rejected")
+	}
+	if _, err := TableFromBytes(append([]byte{0x02}, b[1:]...)); err == nil {
+		t.Fatalf("unknown versions should be rejected")
+	}
+	if _, err := NewTable(0, 8); err == nil {
+		t.Fatalf("empty baby steps should be rejected")
+	}
+	if _, err := NewTable(8, MaxGiantBits+1); err == nil {
+		t.Fatalf("too many giant steps should be rejected")
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	table, err := NewTable(DefaultBabyBits, DefaultGiantBits)
+	if err != nil {
+		b.Fatal(err)
+	}
+	sk := curve.SampleScalar()
+	ct, _ := Encrypt(nil, curve.MulGen(sk).Encode(), 1<<24+12345)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decrypt(sk, ct, table); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package elgamal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+)
+
+// Default table parameters, to decrypt 32-bit amounts with 2^16 baby steps
+// and at most 2^16 giant steps.
+const (
+	DefaultBabyBits  = 16
+	DefaultGiantBits = 16
+)
+
+// Bounds on the table parameters.
+const (
+	MaxBabyBits  = 24
+	MaxGiantBits = 32
+)
+
+// Version of the serialized table format.
+const TableVersion byte = 0x01
+
+// Table is a precomputed baby-step giant-step table, to solve m*G = M for
+// m in [0, 2^(babyBits+giantBits)).
+//
+// The baby steps j*G, for j in [0, 2^babyBits), are indexed by the first
+// limb of their encoding, and M - i*2^babyBits*G is looked up for each
+// giant step i. Matches are checked with a full multiplication, so that
+// first-limb collisions, or a corrupted serialized table, can never yield
+// a wrong amount. A table is safe for concurrent use.
+type Table struct {
+	babyBits  uint8
+	giantBits uint8
+	// Index of each baby step, by first limb; the (unlikely) colliding
+	// baby steps are in overflow.
+	baby     map[uint64]uint32
+	overflow map[uint64][]uint32
+	// -2^babyBits*G
+	giant curve.ECgFp5Point
+}
+
+// NewTable precomputes the table for amounts in
+// [0, 2^(babyBits+giantBits)). The precomputation takes 2^babyBits point
+// additions, and decryption at most 2^giantBits.
+func NewTable(babyBits, giantBits uint8) (*Table, error) {
+	if err := checkTableParams(babyBits, giantBits); err != nil {
+		return nil, err
+	}
+	t := newTable(babyBits, giantBits)
+	p := curve.NEUTRAL_ECgFp5Point
+	for j := uint32(0); j < 1<<babyBits; j++ {
+		t.insert(key(p), j)
+		p = p.Add(curve.GENERATOR_ECgFp5Point)
+	}
+	return t, nil
+}
+
+func checkTableParams(babyBits, giantBits uint8) error {
+	if babyBits == 0 || babyBits > MaxBabyBits {
+		return fmt.Errorf("invalid baby steps, must be between 1 and %d bits", MaxBabyBits)
+	}
+	if giantBits > MaxGiantBits {
+		return fmt.Errorf("invalid giant steps, must be at most %d bits", MaxGiantBits)
+	}
+	return nil
+}
+
+func newTable(babyBits, giantBits uint8) *Table {
+	return &Table{
+		babyBits:  babyBits,
+		giantBits: giantBits,
+		baby:      make(map[uint64]uint32, 1<<babyBits),
+		overflow:  make(map[uint64][]uint32),
+		giant:     curve.MulGen(amountScalar(uint64(1) << babyBits)).Neg(),
+	}
+}
+
+func (t *Table) insert(k uint64, j uint32) {
+	if _, ok := t.baby[k]; ok {
+		t.overflow[k] = append(t.overflow[k], j)
+		return
+	}
+	t.baby[k] = j
+}
+
+func key(p curve.ECgFp5Point) uint64 {
+	return p.Encode()[0].ToCanonicalUint64()
+}
+
+// MaxAmount returns the largest amount which can be decrypted with this
+// table.
+func (t *Table) MaxAmount() uint64 {
+	return uint64(1)<<(t.babyBits+t.giantBits) - 1
+}
+
+// ErrOutOfRange is returned when the amount is not in the range of the
+// table (e.g. the ciphertext was not encrypted to this key, or the amount
+// is negative).
+var ErrOutOfRange = errors.New("amount out of range of the table")
+
+// Solve finds m in the range of the table such that m*G = M.
+//
+// WARNING: this function is vartime in m.
+func (t *Table) Solve(M curve.ECgFp5Point) (uint64, error) {
+	p := M
+	for i := uint64(0); i < 1<<t.giantBits; i++ {
+		k := key(p)
+		if j, ok := t.baby[k]; ok {
+			if m := i<<t.babyBits | uint64(j); curve.MulGen(amountScalar(m)).Equals(M) {
+				return m, nil
+			}
+			for _, j := range t.overflow[k] {
+				if m := i<<t.babyBits | uint64(j); curve.MulGen(amountScalar(m)).Equals(M) {
+					return m, nil
+				}
+			}
+		}
+		p = p.Add(t.giant)
+	}
+	return 0, ErrOutOfRange
+}
+
+// Bytes returns the serialized table, to avoid the precomputation on
+// each start:
+//
+//	TableVersion (1) || babyBits (1) || giantBits (1)
+//	|| first limbs of j*G for j in [0, 2^babyBits) (8 bytes each, little-endian)
+func (t *Table) Bytes() []byte {
+	n := 1 << t.babyBits
+	keys := make([]uint64, n)
+	for k, j := range t.baby {
+		keys[j] = k
+	}
+	for k, js := range t.overflow {
+		for _, j := range js {
+			keys[j] = k
+		}
+	}
+
+	res := make([]byte, 0, 3+8*n)
+	res = append(res, TableVersion, t.babyBits, t.giantBits)
+	for _, k := range keys {
+		res = binary.LittleEndian.AppendUint64(res, k)
+	}
+	return res
+}
+
+// TableFromBytes decodes a serialized table.
+func TableFromBytes(b []byte) (*Table, error) {
+	if len(b) < 3 {
+		return nil, errors.New("invalid table length")
+	}
+	if b[0] != TableVersion {
+		return nil, fmt.Errorf("unknown table version 0x%02x", b[0])
+	}
+	babyBits, giantBits := b[1], b[2]
+	if err := checkTableParams(babyBits, giantBits); err != nil {
+		return nil, err
+	}
+	n := 1 << babyBits
+	if len(b) != 3+8*n {
+		return nil, fmt.Errorf("invalid table length, must be %d bytes", 3+8*n)
+	}
+
+	t := newTable(babyBits, giantBits)
+	for j := 0; j < n; j++ {
+		t.insert(binary.LittleEndian.Uint64(b[3+8*j:]), uint32(j)) //nolint:gosec
+	}
+	return t, nil
+}
+
+// Save writes the serialized table to a file.
+func (t *Table) Save(path string) error {
+	return os.WriteFile(path, t.Bytes(), 0o644) //nolint:gosec
+}
+
+// LoadTable reads a table written by Save.
+func LoadTable(path string) (*Table, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	return TableFromBytes(b)
+}
@@ -125,6 +125,38 @@ func SquareMod(x uint64) uint64 {
 	return res.Uint64()
 }
 
+func TestPackBytes(t *testing.T) {
+	b := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	packed := g.PackBytes(b)
+	expected := []g.GoldilocksField{9, 0x07060504030201, 0x0908}
+	if len(packed) != len(expected) {
+		t.Fatalf("PackBytes: expected %d elements, got %d", len(expected), len(packed))
+	}
+	for i := range expected {
+		if packed[i] != expected[i] {
+			t.Fatalf("PackBytes: expected element %d to be %x, got %x", i, expected[i], packed[i])
+		}
+	}
+
+	if len(g.PackBytes(nil)) != 1 || g.PackBytes(nil)[0] != 0 {
+		t.Fatalf("PackBytes: empty input should pack to its length only")
+	}
+	// Trailing zero bytes are not lost.
+	if g.PackBytes([]byte{1})[0] == g.PackBytes([]byte{1, 0})[0] {
+		t.Fatalf("PackBytes: inputs differing by trailing zeros should differ")
+	}
+
+	all := make([]byte, 100)
+	for i := range all {
+		all[i] = 0xff
+	}
+	for _, e := range g.PackBytes(all) {
+		if uint64(e) >= g.ORDER {
+			t.Fatalf("PackBytes: non-canonical output element")
+		}
+	}
+}
+
 func TestAddF(t *testing.T) {
 	for _, lhs := range inputs {
 		for _, rhs := range inputs {
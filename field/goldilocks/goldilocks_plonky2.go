@@ -43,13 +43,12 @@ func (z GoldilocksField) IsZero() bool {
 	return z.ToCanonicalUint64() == 0
 }
 
+// ToCanonicalUint64 returns the value in the 0 to ORDER-1 range. This
+// function is constant-time.
 func (z GoldilocksField) ToCanonicalUint64() uint64 {
-	x := uint64(z)
-	if x >= ORDER {
-		x -= ORDER
-	}
-
-	return x
+	// Subtract ORDER, and add it back if that yielded a borrow.
+	x, b := bits.Sub64(uint64(z), ORDER, 0)
+	return x + (ORDER & -b)
 }
 
 // lhs, rhs in non-canonical form
@@ -106,6 +105,39 @@ func MulF(lhs, rhs GoldilocksField) GoldilocksField {
 	return GoldilocksField(t2)
 }
 
+// mulConstantTime is MulF without the branch on the borrow. That branch is
+// almost never taken for random values, but it is for some special ones
+// (e.g. when squaring -1), which TrySqrtF reaches depending on its input.
+func mulConstantTime(lhs, rhs GoldilocksField) GoldilocksField {
+	x_hi, x_lo := bits.Mul64(uint64(lhs), uint64(rhs))
+
+	x_hi_hi := x_hi >> 32
+	x_hi_lo := x_hi & EPSILON
+
+	t0, borrow := bits.Sub64(x_lo, x_hi_hi, 0)
+	t0 -= EPSILON & -borrow
+	t1 := x_hi_lo * EPSILON
+
+	sum, over := bits.Add64(t0, t1, 0)
+	return GoldilocksField(sum + EPSILON*over)
+}
+
+// expConstantTime is ExpF with mulConstantTime(); the exponent is public.
+func expConstantTime(x GoldilocksField, exponent uint64) GoldilocksField {
+	current := x
+	product := OneF()
+
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			product = mulConstantTime(product, current)
+		}
+		current = mulConstantTime(current, current)
+		exponent >>= 1
+	}
+
+	return product
+}
+
 func SquareF(x GoldilocksField) GoldilocksField {
 	return MulF(x, x)
 }
@@ -140,13 +172,12 @@ func ExpF(x GoldilocksField, exponent uint64) GoldilocksField {
 	return product
 }
 
+// NegF returns -x, in canonical form. This function is constant-time.
 func NegF(x GoldilocksField) GoldilocksField {
-	z := GoldilocksField(0)
-	if !x.IsZero() {
-		z = GoldilocksField(ORDER - x.ToCanonicalUint64())
-	}
-
-	return z
+	c := x.ToCanonicalUint64()
+	// m = 0xFFFFFFFFFFFFFFFF if x != 0, 0 otherwise
+	m := -((c | -c) >> 63)
+	return GoldilocksField((ORDER - c) & m)
 }
 
 func SampleF() GoldilocksField {
@@ -168,6 +199,26 @@ func FromCanonicalLittleEndianBytesF(b []byte) GoldilocksField {
 	return GoldilocksField(binary.LittleEndian.Uint64(b))
 }
 
+// Number of bytes packed into each field element by PackBytes().
+const PACKED_BYTES = 7
+
+// PackBytes maps an arbitrary byte string to field elements, injectively:
+// the first element is the length of b (in bytes), followed by the bytes
+// in little-endian chunks of PACKED_BYTES (the last chunk may be shorter).
+// All output elements are canonical. Since the length comes first, the
+// output can be followed by further elements without ambiguity, which
+// makes it suitable as a prefix for the (unpadded) sponge hashes.
+func PackBytes(b []byte) []GoldilocksField {
+	res := make([]GoldilocksField, 1, 1+(len(b)+PACKED_BYTES-1)/PACKED_BYTES)
+	res[0] = GoldilocksField(uint64(len(b)))
+	for i := 0; i < len(b); i += PACKED_BYTES {
+		var buf [8]byte
+		copy(buf[:PACKED_BYTES], b[i:min(i+PACKED_BYTES, len(b))])
+		res = append(res, GoldilocksField(binary.LittleEndian.Uint64(buf[:])))
+	}
+	return res
+}
+
 // NonCanonical conversion
 func AsUInt128(f GoldilocksField) UInt128 {
 	u := uint64(f)
@@ -198,47 +249,49 @@ func Reduce128Bit(x UInt128) GoldilocksField {
 	return GoldilocksField(t2)
 }
 
+// SqrtF returns a square root of self, or nil if self is not a square.
 func SqrtF(self GoldilocksField) *GoldilocksField {
-	if self.IsZero() {
-		z := GoldilocksField(0)
-		return &z
+	x, ok := TrySqrtF(self)
+	if !ok {
+		return nil
 	}
-	if IsQuadraticResidueF(self) {
-		// reduce first
-		self := GoldilocksField(self.ToCanonicalUint64())
-
-		t := (ORDER - 1) / (1 << TWO_ADICITY)
-		z := POWER_OF_TWO_GENERATOR
-		w := ExpF(self, (t-1)/2)
-		x := MulF(self, w)
-		b := MulF(x, w)
-
-		v := TWO_ADICITY
-
-		for b.ToCanonicalUint64() != 1 {
-			k := 0
-			b2k := b
-			for b2k.ToCanonicalUint64() != 1 {
-				b2k = SquareF(b2k)
-				k++
-			}
-
-			j := v - k - 1
-			w = z
-			for n := int(0); n < j; n++ {
-				w = SquareF(w)
-			}
-
-			z = SquareF(w)
-			b = MulF(b, z)
-			x = MulF(x, w)
-			v = k
-		}
+	return &x
+}
 
-		return &x
+// TrySqrtF computes a square root of x. The returned boolean is true if x
+// is a square; otherwise, the returned value is zero. The root is the same
+// as the one of the usual Tonelli-Shanks algorithm, but this function uses
+// the constant-time variant of RFC 9380 (appendix I.4), and does not
+// branch on x.
+func TrySqrtF(x GoldilocksField) (GoldilocksField, bool) {
+	// ORDER - 1 = 2^TWO_ADICITY * c2, with c2 odd. The algorithm only needs
+	// a primitive 2^TWO_ADICITY-th root of unity, which
+	// POWER_OF_TWO_GENERATOR is (its 2^(TWO_ADICITY-1)-th power is -1); it
+	// is not 7^c2, and must not be replaced by it.
+	const c2 = (ORDER - 1) >> TWO_ADICITY
+
+	z := expConstantTime(x, (c2-1)/2)
+	t := mulConstantTime(mulConstantTime(z, z), x)
+	z = mulConstantTime(z, x)
+	b := t
+	c := POWER_OF_TWO_GENERATOR
+	for i := TWO_ADICITY; i >= 2; i-- {
+		for j := 1; j <= i-2; j++ {
+			b = mulConstantTime(b, b)
+		}
+		// m = 0xFFFFFFFFFFFFFFFF if b != 1, 0 otherwise
+		d := b.ToCanonicalUint64() ^ 1
+		m := -((d | -d) >> 63)
+		z = GoldilocksField(uint64(z) ^ (m & (uint64(z) ^ uint64(mulConstantTime(z, c)))))
+		c = mulConstantTime(c, c)
+		t = GoldilocksField(uint64(t) ^ (m & (uint64(t) ^ uint64(mulConstantTime(t, c)))))
+		b = t
 	}
 
-	return nil
+	// x is a square if and only if z^2 = x.
+	d := mulConstantTime(z, z).ToCanonicalUint64() ^ x.ToCanonicalUint64()
+	ok := ((d | -d) >> 63) ^ 1
+	return GoldilocksField(z.ToCanonicalUint64() & -ok), ok == 1
 }
 
 func IsQuadraticResidueF(x GoldilocksField) bool {
@@ -258,11 +311,9 @@ func IsQuadraticResidueF(x GoldilocksField) bool {
 	}
 }
 
+// InverseOrZero returns 1/self, or zero if self is zero (this is
+// self^(ORDER-2)). This function is constant-time.
 func (self GoldilocksField) InverseOrZero() GoldilocksField {
-	if self.IsZero() {
-		return ZeroF()
-	}
-
 	// base.exp_power_of_2(N) * tail
 	t2 := MulF(SquareF(self), self)
 	t3 := MulF(SquareF(t2), self)
@@ -225,39 +225,53 @@ func Sqrt(x Element) (Element, bool) {
 	muld := g.MulF(three, added)
 	x0f0 := g.MulF(x[0], _f[0])
 	_g := g.AddF(x0f0, muld)
-	s := g.SqrtF(_g)
-	if s == nil {
-		return FP5_ZERO, false
-	}
+	s, ok := g.TrySqrtF(_g)
 
 	eInv := InverseOrZero(e)
-	sFp5 := FromF(*s)
+	sFp5 := FromF(s)
 
-	return Mul(sFp5, eInv), true
+	// If x is not a square, then s is zero, and so is the result.
+	return Mul(sFp5, eInv), ok
 }
 
+// Sgn0 returns true if the first non-zero limb of x is even, or if x is
+// zero. This function is constant-time.
 func Sgn0(x Element) bool {
-	sign := false
-	zero := true
+	return Sgn0Mask(x) != 0
+}
+
+// Sgn0Mask returns 0xFFFFFFFFFFFFFFFF if Sgn0(x) is true, 0 otherwise, for
+// use with Select(). This function is constant-time.
+func Sgn0Mask(x Element) uint64 {
+	sign := uint64(0)
+	zero := uint64(1)
 	for _, limb := range x {
-		sign_i := (limb.ToCanonicalUint64() & 1) == 0
-		zero_i := limb.IsZero()
-		sign = sign || (zero && sign_i)
-		zero = zero && zero_i
+		c := limb.ToCanonicalUint64()
+		sign_i := (c & 1) ^ 1
+		zero_i := ((c | -c) >> 63) ^ 1
+		sign |= zero & sign_i
+		zero &= zero_i
 	}
-	return sign
+	return -sign
 }
 
+// CanonicalSqrt returns the square root of x for which Sgn0() is false
+// (or zero), and true; if x is not a square, then zero and false are
+// returned. This function is constant-time: the root is negated with a
+// mask, without branching on its sign.
 func CanonicalSqrt(x Element) (Element, bool) {
 	sqrtX, exists := Sqrt(x)
-	if !exists {
-		return FP5_ZERO, false
-	}
+	return Select(Sgn0Mask(sqrtX), sqrtX, Neg(sqrtX)), exists
+}
 
-	if Sgn0(sqrtX) {
-		return Neg(sqrtX), true
+// Select returns a0 if c == 0, or a1 if c == 0xFFFFFFFFFFFFFFFF.
+// c MUST be equal to 0 or 0xFFFFFFFFFFFFFFFF.
+func Select(c uint64, a0, a1 Element) Element {
+	var r Element
+	for i := range r {
+		r[i] = g.GoldilocksField(uint64(a0[i]) ^ (c & (uint64(a0[i]) ^ uint64(a1[i]))))
 	}
-	return sqrtX, true
+	return r
 }
 
 func ScalarMul(a Element, scalar g.GoldilocksField) Element {
@@ -274,11 +288,9 @@ func Double(a Element) Element {
 	return Add(a, a)
 }
 
+// InverseOrZero returns 1/a, or zero if a is zero. This function is
+// constant-time.
 func InverseOrZero(a Element) Element {
-	if IsZero(a) {
-		return FP5_ZERO
-	}
-
 	d := Frobenius(a)
 	e := Mul(d, Frobenius(d))
 	f := Mul(e, RepeatedFrobenius(e, 2))
@@ -292,7 +304,8 @@ func InverseOrZero(a Element) Element {
 	muld := g.MulF(FP5_W, added)
 	gg := g.AddF(a0b0, muld)
 
-	return ScalarMul(f, gg.Inverse())
+	// gg is zero only if a is zero, and then so is f.
+	return ScalarMul(f, gg.InverseOrZero())
 }
 
 func Frobenius(x Element) Element {
@@ -159,3 +159,67 @@ func fitsInInt128(b *big.Int) bool {
 
 	return b.Cmp(min) >= 0 && b.Cmp(max) <= 0
 }
+
+func signed640ToBigInt(s Signed640) *big.Int {
+	r := new(big.Int)
+	for i := len(s) - 1; i >= 0; i-- {
+		r.Lsh(r, 64)
+		r.Or(r, new(big.Int).SetUint64(s[i]))
+	}
+	if !s.IsNonNegative() {
+		r.Sub(r, new(big.Int).Lsh(big.NewInt(1), 640))
+	}
+	return r
+}
+
+func FuzzTestSigned640Shifted(f *testing.F) {
+	f.Add(uint64(1), uint64(0), uint64(math.MaxUint64), uint64(3), uint8(0))
+	f.Add(uint64(math.MaxUint64), uint64(math.MaxUint64), uint64(1), uint64(2), uint8(64))
+	f.Add(uint64(12345), uint64(67890), uint64(math.MaxUint64), uint64(math.MaxUint64), uint8(200))
+
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 640), big.NewInt(1))
+	wrap := func(v *big.Int) *big.Int {
+		v.And(v, mask)
+		if v.Bit(639) == 1 {
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 640))
+		}
+		return v
+	}
+
+	f.Fuzz(func(t *testing.T, a0, a1, b0, b1 uint64, shift uint8) {
+		a := Signed640FromMulU320([5]uint64{a0, a1, a0, a1, a0}, [5]uint64{b0, b1, 0, 0, 0})
+		b := Signed640FromMulU320([5]uint64{b0, b1, b0, b1, b1}, [5]uint64{a1, 0, 0, 0, 0})
+		b.SubShifted(&a, 1) // make some values negative
+
+		aBig := signed640ToBigInt(a)
+		bBig := signed640ToBigInt(b)
+		s := int32(shift)
+
+		sum := a
+		sum.AddShifted(&b, s)
+		expected := wrap(new(big.Int).Add(aBig, new(big.Int).Lsh(bBig, uint(s))))
+		if signed640ToBigInt(sum).Cmp(expected) != 0 {
+			t.Fatalf("AddShifted: got %v, want %v", signed640ToBigInt(sum), expected)
+		}
+
+		diff := a
+		diff.SubShifted(&b, s)
+		expected = wrap(new(big.Int).Sub(aBig, new(big.Int).Lsh(bBig, uint(s))))
+		if signed640ToBigInt(diff).Cmp(expected) != 0 {
+			t.Fatalf("SubShifted: got %v, want %v", signed640ToBigInt(diff), expected)
+		}
+
+		// Bit length excludes the sign bit.
+		abs := new(big.Int).Set(bBig)
+		if abs.Sign() < 0 {
+			abs.Not(abs)
+		}
+		if b.BitLength() != int32(abs.BitLen()) {
+			t.Fatalf("BitLength: got %d, want %d", b.BitLength(), abs.BitLen())
+		}
+
+		if a.LtUnsigned(&b) != (new(big.Int).And(aBig, mask).Cmp(new(big.Int).And(bBig, mask)) < 0) {
+			t.Fatalf("LtUnsigned mismatch")
+		}
+	})
+}
@@ -1,5 +1,7 @@
 package int
 
+import "math/bits"
+
 // A custom 161-bit integer type; used for splitting a scalar into a
 // fraction. Negative values use two's complement notation; the value
 // is truncated to 161 bits (upper bits in the top limb are ignored).
@@ -14,6 +16,41 @@ func (s Signed161) ToU192() [3]uint64 {
 	return [3]uint64{s[0], s[1], x}
 }
 
+// AddShifted adds b*2^shift to this value. The shift count must be
+// nonnegative; since the value is truncated to 161 bits, larger
+// intermediate values are harmless as long as the final result fits.
+func (s *Signed161) AddShifted(b *Signed161, shift int32) {
+	t := b.shifted(shift)
+	var c uint64
+	for i := 0; i < len(s); i++ {
+		s[i], c = bits.Add64(s[i], t[i], c)
+	}
+}
+
+// SubShifted subtracts b*2^shift from this value. The shift count must be
+// nonnegative.
+func (s *Signed161) SubShifted(b *Signed161, shift int32) {
+	t := b.shifted(shift)
+	var c uint64
+	for i := 0; i < len(s); i++ {
+		s[i], c = bits.Sub64(s[i], t[i], c)
+	}
+}
+
+func (s *Signed161) shifted(shift int32) Signed161 {
+	var t Signed161
+	shiftLimbs := int(shift >> 6)
+	shiftBits := uint(shift & 63)
+	for i := len(s) - 1; i >= shiftLimbs; i-- {
+		w := s[i-shiftLimbs] << shiftBits
+		if shiftBits != 0 && i > shiftLimbs {
+			w |= s[i-shiftLimbs-1] >> (64 - shiftBits)
+		}
+		t[i] = w
+	}
+	return t
+}
+
 // Recode this integer into 33 signed digits for a 5-bit window.
 func RecodeSigned5(s Signed161) [33]int32 {
 	// We first sign-extend the value to 192 bits, then add
@@ -28,6 +65,12 @@ func RecodeSigned5(s Signed161) [33]int32 {
 	return ss
 }
 
+// RecodeSignedFromLimbs recodes an unsigned integer (64-bit limbs, in
+// little-endian order) into signed digits for a w-bit window; see
+// ECgFp5Scalar.RecodeSigned().
+// Unlike the rest of this file, this function is constant-time: its
+// control flow only depends on the lengths of limbs and ss, and on w, so
+// it may be used on secret scalars.
 func RecodeSignedFromLimbs(limbs []uint64, ss []int32, w int32) {
 	var acc uint64 = 0
 	var accLen int32 = 0
@@ -0,0 +1,102 @@
+package int
+
+import "math/bits"
+
+// A custom 640-bit integer type; used for the norms and scalar products
+// of the lattice basis reduction. Negative values use two's complement
+// notation. Elements are mutable containers.
+// WARNING: everything in here is vartime; do not use on secret values.
+type Signed640 [10]uint64
+
+// Signed640FromMulU320 returns the product of two 320-bit unsigned
+// integers (five 64-bit limbs each, in little-endian order).
+func Signed640FromMulU320(a, b [5]uint64) Signed640 {
+	var r Signed640
+	for i := 0; i < 5; i++ {
+		var cc uint64
+		for j := 0; j < 5; j++ {
+			hi, lo := bits.Mul64(a[i], b[j])
+			var c uint64
+			lo, c = bits.Add64(lo, r[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, cc, 0)
+			hi += c
+			r[i+j] = lo
+			cc = hi
+		}
+		r[i+5] = cc
+	}
+	return r
+}
+
+// AddUint64 adds a small unsigned integer to this value.
+func (s *Signed640) AddUint64(v uint64) {
+	var c uint64
+	s[0], c = bits.Add64(s[0], v, 0)
+	for i := 1; i < len(s); i++ {
+		s[i], c = bits.Add64(s[i], 0, c)
+	}
+}
+
+// IsNonNegative returns true if this value is zero or positive.
+func (s *Signed640) IsNonNegative() bool {
+	return (s[9] >> 63) == 0
+}
+
+// LtUnsigned returns true if this value is lower than rhs, both values
+// being interpreted as unsigned integers.
+func (s *Signed640) LtUnsigned(rhs *Signed640) bool {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] != rhs[i] {
+			return s[i] < rhs[i]
+		}
+	}
+	return false
+}
+
+// BitLength returns the bit length of this value, i.e. the minimal size
+// of a two's complement representation, excluding the sign bit.
+func (s *Signed640) BitLength() int32 {
+	m := -(s[9] >> 63)
+	for i := len(s) - 1; i >= 0; i-- {
+		w := s[i] ^ m
+		if w != 0 {
+			return int32(i<<6 + bits.Len64(w)) //nolint:gosec
+		}
+	}
+	return 0
+}
+
+// AddShifted adds b*2^shift to this value. The shift count must be
+// nonnegative; bits beyond 640 are dropped.
+func (s *Signed640) AddShifted(b *Signed640, shift int32) {
+	t := b.shifted(shift)
+	var c uint64
+	for i := 0; i < len(s); i++ {
+		s[i], c = bits.Add64(s[i], t[i], c)
+	}
+}
+
+// SubShifted subtracts b*2^shift from this value. The shift count must be
+// nonnegative; bits beyond 640 are dropped.
+func (s *Signed640) SubShifted(b *Signed640, shift int32) {
+	t := b.shifted(shift)
+	var c uint64
+	for i := 0; i < len(s); i++ {
+		s[i], c = bits.Sub64(s[i], t[i], c)
+	}
+}
+
+func (s *Signed640) shifted(shift int32) Signed640 {
+	var t Signed640
+	shiftLimbs := int(shift >> 6)
+	shiftBits := uint(shift & 63)
+	for i := len(s) - 1; i >= shiftLimbs; i-- {
+		w := s[i-shiftLimbs] << shiftBits
+		if shiftBits != 0 && i > shiftLimbs {
+			w |= s[i-shiftLimbs-1] >> (64 - shiftBits)
+		}
+		t[i] = w
+	}
+	return t
+}
@@ -0,0 +1,235 @@
+package frost
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	"github.com/elliottech/poseidon_crypto/vss"
+)
+
+// Distributed key generation, as in the FROST paper (and RFC 9591,
+// appendix C): each participant i deals a Feldman sharing of a random
+// secret a_i0, with a proof of knowledge of a_i0 to prevent rogue-key
+// attacks. The group secret key is \sum_i a_i0, and nobody learns it.
+//
+//  1. Each participant runs NewDKGParticipant() and broadcasts its
+//     DKGRound1Package (commitment and proof of knowledge).
+//  2. Once it has received all round 1 packages, each participant runs
+//     Round2(), which checks them, and sends each DKGRound2Package to its
+//     recipient over a confidential and authenticated channel.
+//  3. Each participant runs Finalize() on the round 2 packages it
+//     received, which checks the shares against the commitments, and
+//     obtains its key package and the public key package.
+//
+// Participants whose packages fail the checks are reported with a
+// *CulpritError.
+
+// DKGRound1Package is broadcast by each participant: the commitment to
+// its polynomial, and a Schnorr proof of knowledge (R, z) of the
+// constant coefficient.
+type DKGRound1Package struct {
+	ID         uint32
+	Commitment vss.Commitment
+	ProofR     curve.ECgFp5Point
+	ProofZ     curve.ECgFp5Scalar
+}
+
+// DKGRound2Package is the share f_From(To) sent privately by participant
+// From to participant To. It is a secret value.
+type DKGRound2Package struct {
+	From, To uint32
+	Share    curve.ECgFp5Scalar
+}
+
+// DKGParticipant is the state of a participant during the key
+// generation. It holds secret values.
+type DKGParticipant struct {
+	id        uint32
+	threshold int
+	n         int
+	poly      vss.Polynomial
+	round1    map[uint32]*DKGRound1Package
+}
+
+// NewDKGParticipant starts the key generation for participant id among
+// participants 1 to n, with threshold t, and returns its round 1 package.
+// The secret polynomial and the proof nonce are sampled from rand (or
+// crypto/rand if rand is nil).
+func NewDKGParticipant(id uint32, threshold, n int, rand io.Reader) (*DKGParticipant, *DKGRound1Package, error) {
+	if threshold < 1 || threshold > n {
+		return nil, nil, errors.New("threshold must be between 1 and the number of participants")
+	}
+	if id == 0 || uint64(id) > uint64(n) { //nolint:gosec
+		return nil, nil, errors.New("participant identifier must be between 1 and the number of participants")
+	}
+
+	secret, err := curve.SampleScalarFrom(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	poly, err := vss.NewRandomPolynomial(secret, threshold-1, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	commitment := poly.Commit()
+	k, err := curve.SampleScalarFrom(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := curve.MulGen(k)
+	c := dkgChallenge(id, commitment[0], r)
+
+	p := &DKGParticipant{
+		id:        id,
+		threshold: threshold,
+		n:         n,
+		poly:      poly,
+		round1:    nil,
+	}
+	return p, &DKGRound1Package{
+		ID:         id,
+		Commitment: commitment,
+		ProofR:     r,
+		ProofZ:     k.Add(poly[0].Mul(c)),
+	}, nil
+}
+
+// Challenge of the proof of knowledge:
+// c = HashToScalar(DKG_PROOF_TAG || i || C_i0 || R).
+func dkgChallenge(id uint32, c0, r curve.ECgFp5Point) curve.ECgFp5Scalar {
+	input := make([]g.GoldilocksField, 0, 1+1+5+5)
+	input = append(input, DKG_PROOF_TAG, g.GoldilocksField(uint64(id)))
+	input = appendElement(input, c0.Encode())
+	input = appendElement(input, r.Encode())
+	return curve.HashToScalar(input)
+}
+
+// Verify the shape and the proof of knowledge of a round 1 package:
+// z*G - c*C_i0 = R.
+func (p *DKGParticipant) verifyRound1(pkg *DKGRound1Package) bool {
+	if len(pkg.Commitment) != p.threshold || !pkg.ProofZ.IsCanonical() {
+		return false
+	}
+	c := dkgChallenge(pkg.ID, pkg.Commitment[0], pkg.ProofR)
+	return pkg.Commitment[0].MulAddGenVarTime(pkg.ProofZ, c.Neg()).Equals(pkg.ProofR)
+}
+
+// Round2 checks the round 1 packages of all other participants, and
+// returns the shares to send to each of them.
+func (p *DKGParticipant) Round2(round1 []*DKGRound1Package) ([]*DKGRound2Package, error) {
+	if p.poly == nil {
+		return nil, errors.New("key generation is already finalized")
+	}
+	byID, err := p.collect(len(round1), func(i int) uint32 { return round1[i].ID })
+	if err != nil {
+		return nil, err
+	}
+
+	var culprits []uint32
+	p.round1 = make(map[uint32]*DKGRound1Package, len(round1))
+	for _, pkg := range round1 {
+		if !p.verifyRound1(pkg) {
+			culprits = append(culprits, pkg.ID)
+		}
+		p.round1[pkg.ID] = pkg
+	}
+	if len(culprits) > 0 {
+		p.round1 = nil
+		return nil, &CulpritError{Culprits: sortIDs(culprits), Reason: "invalid round 1 packages"}
+	}
+
+	res := make([]*DKGRound2Package, 0, len(byID))
+	for _, id := range participantIDs(p.n) {
+		if id == p.id {
+			continue
+		}
+		res = append(res, &DKGRound2Package{
+			From:  p.id,
+			To:    id,
+			Share: p.poly.Evaluate(vss.IndexScalar(id)),
+		})
+	}
+	return res, nil
+}
+
+// Finalize checks the shares received from all other participants
+// against their commitments, and computes the key packages. The secret
+// polynomial is erased.
+func (p *DKGParticipant) Finalize(round2 []*DKGRound2Package) (*KeyPackage, *PublicKeyPackage, error) {
+	if p.round1 == nil {
+		return nil, nil, errors.New("round 2 has not been completed")
+	}
+	if _, err := p.collect(len(round2), func(i int) uint32 { return round2[i].From }); err != nil {
+		return nil, nil, err
+	}
+
+	var culprits []uint32
+	secret := p.poly.Evaluate(vss.IndexScalar(p.id))
+	for _, pkg := range round2 {
+		if pkg.To != p.id {
+			return nil, nil, fmt.Errorf("round 2 package from participant %d is addressed to participant %d", pkg.From, pkg.To)
+		}
+		if !p.round1[pkg.From].Commitment.Verify(vss.Share{Index: p.id, Value: pkg.Share}) {
+			culprits = append(culprits, pkg.From)
+			continue
+		}
+		secret = secret.Add(pkg.Share)
+	}
+	if len(culprits) > 0 {
+		return nil, nil, &CulpritError{Culprits: sortIDs(culprits), Reason: "invalid round 2 shares"}
+	}
+
+	groupCommitment := p.poly.Commit()
+	for _, pkg := range p.round1 {
+		// Lengths were checked in Round2().
+		groupCommitment, _ = groupCommitment.Add(pkg.Commitment)
+	}
+	pkp := newPublicKeyPackage(groupCommitment, participantIDs(p.n), p.threshold)
+	kp := &KeyPackage{
+		ID:                p.id,
+		SecretShare:       secret,
+		VerificationShare: pkp.VerificationShares[p.id],
+		GroupPublicKey:    pkp.GroupPublicKey,
+		Threshold:         p.threshold,
+	}
+	if !curve.MulGen(secret).Equals(kp.VerificationShare) {
+		return nil, nil, errors.New("secret share does not match the verification share")
+	}
+	if pkp.GroupPublicKey.IsNeutral() {
+		return nil, nil, errors.New("group public key is the neutral element")
+	}
+
+	for i := range p.poly {
+		p.poly[i] = curve.ZERO
+	}
+	p.poly = nil
+	return kp, pkp, nil
+}
+
+// Check that a list of packages has exactly one package from each other
+// participant, and return the set of identifiers.
+func (p *DKGParticipant) collect(count int, id func(int) uint32) (map[uint32]bool, error) {
+	if count != p.n-1 {
+		return nil, fmt.Errorf("expected %d packages, got %d", p.n-1, count)
+	}
+	seen := make(map[uint32]bool, count)
+	for i := 0; i < count; i++ {
+		from := id(i)
+		if from == 0 || uint64(from) > uint64(p.n) || from == p.id { //nolint:gosec
+			return nil, fmt.Errorf("unexpected package from participant %d", from)
+		}
+		if seen[from] {
+			return nil, fmt.Errorf("duplicate package from participant %d", from)
+		}
+		seen[from] = true
+	}
+	return seen, nil
+}
+
+func sortIDs(ids []uint32) []uint32 {
+	res, _ := sortedIDs(ids)
+	return res
+}
@@ -0,0 +1,154 @@
+// Package frost implements FROST threshold Schnorr signatures over the
+// ECgFp5 group (Komlo and Goldberg, "FROST: Flexible Round-Optimized
+// Schnorr Threshold Signatures", SAC 2020), following the structure of
+// RFC 9591 with the two-round signing protocol.
+//
+// A group of n participants holds Shamir shares of a secret key, and any
+// t of them can sign together; the secret key is never reconstructed. The
+// output is a standard Schnorr signature (see package signature/schnorr)
+// for the group public key, accepted by the unmodified
+// IsSchnorrSignatureValid().
+//
+// PROTOCOL:
+//
+//  1. Key generation, either with a trusted dealer (TrustedDealerKeygen)
+//     or with the distributed key generation of dkg.go, which yields a
+//     KeyPackage for each participant and a shared PublicKeyPackage.
+//  2. Commitment (preprocessing): each signer runs Commit(), keeps the
+//     nonces and sends the commitment to the coordinator.
+//  3. Signing: the coordinator picks at least t commitments and sends the
+//     SigningPackage to the signers, which answer with Sign(). The
+//     coordinator combines the shares with Aggregate(), which identifies
+//     the signers that sent invalid shares.
+//
+// With binding factors rho_i, the group commitment is
+// R = \sum_i (D_i + rho_i*E_i), the challenge is e = H(R || H(m)) as in
+// package signature/schnorr, and each signer contributes
+// z_i = d_i + rho_i*e_i - lambda_i*e*x_i, where lambda_i is its Lagrange
+// coefficient, so that z = \sum_i z_i fulfills z*G + e*X = R.
+package frost
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	"github.com/elliottech/poseidon_crypto/vss"
+)
+
+// Domain separation tags, as ASCII strings read as little-endian 64-bit
+// integers.
+const (
+	// "frost-nc": nonce generation.
+	NONCE_TAG = g.GoldilocksField(0x636e2d74736f7266)
+	// "frost-cl": hash of the commitment list.
+	COMMITMENT_LIST_TAG = g.GoldilocksField(0x6c632d74736f7266)
+	// "frost-bf": binding factors.
+	BINDING_FACTOR_TAG = g.GoldilocksField(0x66622d74736f7266)
+	// "frost-pk": proof of knowledge in the distributed key generation.
+	DKG_PROOF_TAG = g.GoldilocksField(0x6b702d74736f7266)
+)
+
+// KeyPackage is the key material of a participant. The secret share is
+// a secret value.
+type KeyPackage struct {
+	ID                uint32
+	SecretShare       curve.ECgFp5Scalar
+	VerificationShare curve.ECgFp5Point
+	GroupPublicKey    curve.ECgFp5Point
+	Threshold         int
+}
+
+// PublicKeyPackage holds the public key material of the group: the group
+// public key and the verification share X_i = x_i*G of each participant.
+type PublicKeyPackage struct {
+	VerificationShares map[uint32]curve.ECgFp5Point
+	GroupPublicKey     curve.ECgFp5Point
+	Threshold          int
+}
+
+// PublicKey returns the encoded group public key, which verifies the
+// signatures of the group.
+func (p *PublicKeyPackage) PublicKey() gFp5.Element {
+	return p.GroupPublicKey.Encode()
+}
+
+// CulpritError reports the participants identified as misbehaving.
+type CulpritError struct {
+	Culprits []uint32
+	Reason   string
+}
+
+func (e *CulpritError) Error() string {
+	return fmt.Sprintf("%s (participants %v)", e.Reason, e.Culprits)
+}
+
+// TrustedDealerKeygen shares the secret key between participants 1 to n,
+// with threshold t, and a sharing polynomial sampled from rand (or
+// crypto/rand if rand is nil). The dealer knows the secret key, and must
+// erase it along with the key packages once they are distributed.
+func TrustedDealerKeygen(secret curve.ECgFp5Scalar, threshold, n int, rand io.Reader) ([]*KeyPackage, *PublicKeyPackage, error) {
+	if secret.Equals(curve.ZERO) || !secret.IsCanonical() {
+		return nil, nil, errors.New("invalid secret key")
+	}
+	shares, commitment, err := vss.Split(secret, threshold, n, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkp := newPublicKeyPackage(commitment, participantIDs(n), threshold)
+
+	kps := make([]*KeyPackage, n)
+	for i, s := range shares {
+		kps[i] = &KeyPackage{
+			ID:                s.Index,
+			SecretShare:       s.Value,
+			VerificationShare: pkp.VerificationShares[s.Index],
+			GroupPublicKey:    pkp.GroupPublicKey,
+			Threshold:         threshold,
+		}
+	}
+	return kps, pkp, nil
+}
+
+// Identifiers 1 to n.
+func participantIDs(n int) []uint32 {
+	ids := make([]uint32, n)
+	for i := range ids {
+		ids[i] = uint32(i + 1) //nolint:gosec
+	}
+	return ids
+}
+
+// Derive the public key package from the commitment to the polynomial
+// which shares the group secret key.
+func newPublicKeyPackage(commitment vss.Commitment, ids []uint32, threshold int) *PublicKeyPackage {
+	pkp := &PublicKeyPackage{
+		VerificationShares: make(map[uint32]curve.ECgFp5Point, len(ids)),
+		GroupPublicKey:     commitment[0],
+		Threshold:          threshold,
+	}
+	for _, id := range ids {
+		pkp.VerificationShares[id] = commitment.Evaluate(id)
+	}
+	return pkp
+}
+
+// Sorted copy of a list of identifiers; an error is returned if it
+// contains zero or duplicates.
+func sortedIDs(ids []uint32) ([]uint32, error) {
+	res := append([]uint32(nil), ids...)
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	for i, id := range res {
+		if id == 0 {
+			return nil, errors.New("invalid participant identifier 0")
+		}
+		if i > 0 && res[i-1] == id {
+			return nil, fmt.Errorf("duplicate participant identifier %d", id)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,352 @@
+package frost
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+)
+
+// Run the commitment round for the signers, and return their nonces and
+// the signing package.
+func commitRound(t *testing.T, signers []*KeyPackage, hashedMsg gFp5.Element) ([]*SigningNonces, *SigningPackage) {
+	nonces := make([]*SigningNonces, len(signers))
+	commitments := make([]SigningCommitment, len(signers))
+	for i, kp := range signers {
+		n, c, err := Commit(kp, nil)
+		if err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+		nonces[i], commitments[i] = n, c
+	}
+	sp, err := NewSigningPackage(commitments, hashedMsg)
+	if err != nil {
+		t.Fatalf("NewSigningPackage failed: %v", err)
+	}
+	return nonces, sp
+}
+
+func signAll(t *testing.T, signers []*KeyPackage, nonces []*SigningNonces, sp *SigningPackage) []SignatureShare {
+	shares := make([]SignatureShare, len(signers))
+	for i, kp := range signers {
+		share, err := Sign(kp, nonces[i], sp)
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		shares[i] = share
+	}
+	return shares
+}
+
+func checkSigning(t *testing.T, kps []*KeyPackage, pkp *PublicKeyPackage, signers []*KeyPackage) {
+	hashedMsg := gFp5.Sample()
+	nonces, sp := commitRound(t, signers, hashedMsg)
+	shares := signAll(t, signers, nonces, sp)
+	for _, s := range shares {
+		if !VerifySignatureShare(pkp, sp, s) {
+			t.Fatalf("share of participant %d is invalid", s.ID)
+		}
+	}
+
+	sig, err := Aggregate(pkp, sp, shares)
+	if err != nil {
+		t.Fatalf("Aggregate failed: %v", err)
+	}
+	if !schnorr.IsSchnorrSignatureValid(pkp.PublicKey(), hashedMsg, sig) {
+		t.Fatalf("signature is invalid")
+	}
+	if schnorr.IsSchnorrSignatureValid(pkp.PublicKey(), gFp5.Sample(), sig) {
+		t.Fatalf("signature should not verify another message")
+	}
+	for _, kp := range kps {
+		if !kp.GroupPublicKey.Equals(pkp.GroupPublicKey) {
+			t.Fatalf("participant %d has a different group public key", kp.ID)
+		}
+		if !curve.MulGen(kp.SecretShare).Equals(pkp.VerificationShares[kp.ID]) {
+			t.Fatalf("participant %d has an invalid verification share", kp.ID)
+		}
+	}
+}
+
+func TestTrustedDealer(t *testing.T) {
+	sk := curve.SampleScalar()
+	kps, pkp, err := TrustedDealerKeygen(sk, 3, 5, nil)
+	if err != nil {
+		t.Fatalf("TrustedDealerKeygen failed: %v", err)
+	}
+	if !gFp5.Equals(pkp.PublicKey(), schnorr.SchnorrPkFromSk(sk)) {
+		t.Fatalf("group public key does not match the secret key")
+	}
+
+	for _, signers := range [][]*KeyPackage{
+		kps[:3],
+		{kps[4], kps[1], kps[2]},
+		kps[1:],
+		kps,
+	} {
+		checkSigning(t, kps, pkp, signers)
+	}
+
+	if _, _, err := TrustedDealerKeygen(curve.ZERO, 2, 3, nil); err == nil {
+		t.Fatalf("zero secret key should be rejected")
+	}
+	if _, _, err := TrustedDealerKeygen(sk, 4, 3, nil); err == nil {
+		t.Fatalf("threshold larger than n should be rejected")
+	}
+}
+
+// Run the distributed key generation between n participants.
+func runDKG(t *testing.T, threshold, n int) ([]*DKGParticipant, []*DKGRound1Package) {
+	participants := make([]*DKGParticipant, n)
+	round1 := make([]*DKGRound1Package, n)
+	for i := range participants {
+		p, pkg, err := NewDKGParticipant(uint32(i+1), threshold, n, nil) //nolint:gosec
+		if err != nil {
+			t.Fatalf("NewDKGParticipant failed: %v", err)
+		}
+		participants[i], round1[i] = p, pkg
+	}
+	return participants, round1
+}
+
+// Packages of all participants except i.
+func others[T any](pkgs []T, i int) []T {
+	res := make([]T, 0, len(pkgs)-1)
+	res = append(res, pkgs[:i]...)
+	return append(res, pkgs[i+1:]...)
+}
+
+func TestDKG(t *testing.T) {
+	for _, tc := range []struct{ threshold, n int }{{1, 1}, {2, 2}, {2, 3}, {3, 5}} {
+		participants, round1 := runDKG(t, tc.threshold, tc.n)
+
+		received := make([][]*DKGRound2Package, tc.n)
+		for i, p := range participants {
+			out, err := p.Round2(others(round1, i))
+			if err != nil {
+				t.Fatalf("Round2 failed: %v", err)
+			}
+			for _, pkg := range out {
+				received[pkg.To-1] = append(received[pkg.To-1], pkg)
+			}
+		}
+
+		kps := make([]*KeyPackage, tc.n)
+		var pkp *PublicKeyPackage
+		for i, p := range participants {
+			kp, pkpi, err := p.Finalize(received[i])
+			if err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+			if pkp != nil && !reflect.DeepEqual(pkp.PublicKey(), pkpi.PublicKey()) {
+				t.Fatalf("participants disagree on the group public key")
+			}
+			kps[i], pkp = kp, pkpi
+		}
+		checkSigning(t, kps, pkp, kps[:tc.threshold])
+		checkSigning(t, kps, pkp, kps[tc.n-tc.threshold:])
+
+		if _, err := participants[0].Round2(others(round1, 0)); err == nil {
+			t.Fatalf("Round2 after Finalize should fail")
+		}
+	}
+}
+
+func TestDKGNeutralGroupKey(t *testing.T) {
+	// Participant 2 cancels the secret of participant 1, with a valid
+	// proof of knowledge of its own constant coefficient.
+	participants, round1 := runDKG(t, 1, 2)
+	p2 := participants[1]
+	p2.poly[0] = participants[0].poly[0].Neg()
+	commitment := p2.poly.Commit()
+	k := curve.SampleScalar()
+	r := curve.MulGen(k)
+	round1[1] = &DKGRound1Package{
+		ID:         2,
+		Commitment: commitment,
+		ProofR:     r,
+		ProofZ:     k.Add(p2.poly[0].Mul(dkgChallenge(2, commitment[0], r))),
+	}
+
+	out, err := p2.Round2(others(round1, 1))
+	if err != nil {
+		t.Fatalf("Round2 failed: %v", err)
+	}
+	if _, err := participants[0].Round2(others(round1, 0)); err != nil {
+		t.Fatalf("Round2 failed: %v", err)
+	}
+	if _, _, err := participants[0].Finalize(out); err == nil || err.Error() != "group public key is the neutral element" {
+		t.Fatalf("Finalize should reject a neutral group public key, got %v", err)
+	}
+}
+
+func TestDKGRandomness(t *testing.T) {
+	// Secret, one coefficient and the proof nonce, of 80 bytes each.
+	seed := bytes.Repeat([]byte{7}, 240)
+	_, pkg1, err := NewDKGParticipant(1, 2, 3, bytes.NewReader(seed))
+	if err != nil {
+		t.Fatalf("NewDKGParticipant failed: %v", err)
+	}
+	_, pkg2, _ := NewDKGParticipant(1, 2, 3, bytes.NewReader(seed))
+	if !reflect.DeepEqual(pkg1, pkg2) {
+		t.Fatalf("round 1 packages should be deterministic for a fixed random source")
+	}
+	if _, _, err := NewDKGParticipant(1, 2, 3, bytes.NewReader(seed[:160])); err == nil {
+		t.Fatalf("NewDKGParticipant should report a failing random source")
+	}
+	if _, _, err := TrustedDealerKeygen(curve.ONE, 2, 3, bytes.NewReader(nil)); err == nil {
+		t.Fatalf("TrustedDealerKeygen should report a failing random source")
+	}
+}
+
+func TestDKGCulprits(t *testing.T) {
+	// Invalid proof of knowledge.
+	participants, round1 := runDKG(t, 2, 4)
+	round1[2].ProofZ = round1[2].ProofZ.Add(curve.ONE)
+	_, err := participants[0].Round2(others(round1, 0))
+	checkCulprits(t, err, []uint32{3})
+
+	// Commitment of the wrong degree.
+	participants, round1 = runDKG(t, 2, 4)
+	round1[1].Commitment = round1[1].Commitment[:1]
+	_, err = participants[0].Round2(others(round1, 0))
+	checkCulprits(t, err, []uint32{2})
+
+	// Missing and duplicate packages.
+	if _, err := participants[0].Round2(others(round1, 0)[1:]); err == nil {
+		t.Fatalf("missing round 1 package should be rejected")
+	}
+	if _, err := participants[0].Round2([]*DKGRound1Package{round1[1], round1[1], round1[2]}); err == nil {
+		t.Fatalf("duplicate round 1 package should be rejected")
+	}
+
+	// Invalid share.
+	participants, round1 = runDKG(t, 2, 3)
+	received := make([][]*DKGRound2Package, 3)
+	for i, p := range participants {
+		out, err := p.Round2(others(round1, i))
+		if err != nil {
+			t.Fatalf("Round2 failed: %v", err)
+		}
+		for _, pkg := range out {
+			received[pkg.To-1] = append(received[pkg.To-1], pkg)
+		}
+	}
+	for _, pkg := range received[0] {
+		if pkg.From == 3 {
+			pkg.Share = pkg.Share.Add(curve.ONE)
+		}
+	}
+	_, _, err = participants[0].Finalize(received[0])
+	checkCulprits(t, err, []uint32{3})
+	if _, _, err := participants[1].Finalize(received[0]); err == nil {
+		t.Fatalf("packages addressed to another participant should be rejected")
+	}
+}
+
+func checkCulprits(t *testing.T, err error, want []uint32) {
+	t.Helper()
+	var ce *CulpritError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected a CulpritError, got %v", err)
+	}
+	if !reflect.DeepEqual(ce.Culprits, want) {
+		t.Fatalf("culprits %v, want %v", ce.Culprits, want)
+	}
+}
+
+func TestAggregateCulprits(t *testing.T) {
+	kps, pkp, err := TrustedDealerKeygen(curve.SampleScalar(), 3, 5, nil)
+	if err != nil {
+		t.Fatalf("TrustedDealerKeygen failed: %v", err)
+	}
+	signers := kps[1:]
+	nonces, sp := commitRound(t, signers, gFp5.Sample())
+	shares := signAll(t, signers, nonces, sp)
+
+	// Shares of participants 3 and 5 are invalid.
+	shares[1].Z = shares[1].Z.Add(curve.ONE)
+	shares[3].Z = shares[3].Z.Add(curve.ONE)
+	if VerifySignatureShare(pkp, sp, shares[1]) {
+		t.Fatalf("modified share should be invalid")
+	}
+	_, err = Aggregate(pkp, sp, shares)
+	checkCulprits(t, err, []uint32{3, 5})
+
+	_, err = Aggregate(pkp, sp, shares[:3])
+	checkCulprits(t, err, []uint32{5})
+
+	nonCanonical := append([]SignatureShare(nil), shares...)
+	nonCanonical[2].Z = curve.ECgFp5Scalar{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+	_, err = Aggregate(pkp, sp, nonCanonical)
+	checkCulprits(t, err, []uint32{4})
+
+	if _, err := Aggregate(pkp, sp, append(shares, shares[0])); err == nil {
+		t.Fatalf("duplicate share should be rejected")
+	}
+}
+
+func TestSignErrors(t *testing.T) {
+	kps, _, err := TrustedDealerKeygen(curve.SampleScalar(), 2, 3, nil)
+	if err != nil {
+		t.Fatalf("TrustedDealerKeygen failed: %v", err)
+	}
+	hashedMsg := gFp5.Sample()
+
+	// Not enough signers.
+	nonces, sp := commitRound(t, kps[:1], hashedMsg)
+	if _, err := Sign(kps[0], nonces[0], sp); err == nil {
+		t.Fatalf("signing below the threshold should fail")
+	}
+
+	// Nonce reuse.
+	nonces, sp = commitRound(t, kps[:2], hashedMsg)
+	if _, err := Sign(kps[0], nonces[0], sp); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := Sign(kps[0], nonces[0], sp); err == nil {
+		t.Fatalf("reusing nonces should fail")
+	}
+
+	// Nonces of another participant, and signer not in the package.
+	if _, err := Sign(kps[0], nonces[1], sp); err == nil {
+		t.Fatalf("signing with the nonces of another participant should fail")
+	}
+	other, _, _ := Commit(kps[2], nil)
+	if _, err := Sign(kps[2], other, sp); err == nil {
+		t.Fatalf("signing without being in the signing package should fail")
+	}
+
+	// Fresh nonces whose commitment is not in the package.
+	fresh, _, _ := Commit(kps[1], nil)
+	if _, err := Sign(kps[1], fresh, sp); err == nil {
+		t.Fatalf("signing with nonces not in the signing package should fail")
+	}
+
+	c := nonces[0].Commitment()
+	if _, err := NewSigningPackage([]SigningCommitment{c, c}, hashedMsg); err == nil {
+		t.Fatalf("duplicate commitments should be rejected")
+	}
+}
+
+func BenchmarkSign(b *testing.B) {
+	kps, _, _ := TrustedDealerKeygen(curve.SampleScalar(), 3, 5, nil)
+	commitments := make([]SigningCommitment, 3)
+	nonces := make([]*SigningNonces, 3)
+	for i := range commitments {
+		nonces[i], commitments[i], _ = Commit(kps[i], nil)
+	}
+	sp, _ := NewSigningPackage(commitments, gFp5.Sample())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := *nonces[0]
+		if _, err := Sign(kps[0], &n, sp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
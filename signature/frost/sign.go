@@ -0,0 +1,318 @@
+package frost
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+	"github.com/elliottech/poseidon_crypto/vss"
+)
+
+// SigningCommitment is the public part of a signer's nonces:
+// (D_i, E_i) = (d_i*G, e_i*G).
+type SigningCommitment struct {
+	ID      uint32
+	Hiding  curve.ECgFp5Point
+	Binding curve.ECgFp5Point
+}
+
+// SigningNonces holds the secret nonces (d_i, e_i) of a signer. They must
+// be used for at most one signature; Sign() erases them.
+type SigningNonces struct {
+	hiding, binding curve.ECgFp5Scalar
+	commitment      SigningCommitment
+}
+
+// SignatureShare is the contribution z_i of a signer.
+type SignatureShare struct {
+	ID uint32
+	Z  curve.ECgFp5Scalar
+}
+
+// Commit generates fresh nonces for the participant, with randomness from
+// rand (or crypto/rand if rand is nil). As in RFC 9591, each nonce is
+// derived from 32 random bytes and the secret share:
+//
+//	k = HashToScalar(NONCE_TAG || rand || x_i || j)
+//
+// with j = 1 for the hiding nonce and j = 2 for the binding nonce.
+func Commit(kp *KeyPackage, rand io.Reader) (*SigningNonces, SigningCommitment, error) {
+	hiding, err := generateNonce(kp.SecretShare, 1, rand)
+	if err != nil {
+		return nil, SigningCommitment{}, err
+	}
+	binding, err := generateNonce(kp.SecretShare, 2, rand)
+	if err != nil {
+		return nil, SigningCommitment{}, err
+	}
+
+	nonces := &SigningNonces{
+		hiding:  hiding,
+		binding: binding,
+		commitment: SigningCommitment{
+			ID:      kp.ID,
+			Hiding:  curve.MulGen(hiding),
+			Binding: curve.MulGen(binding),
+		},
+	}
+	return nonces, nonces.commitment, nil
+}
+
+func generateNonce(secret curve.ECgFp5Scalar, j uint64, rand io.Reader) (curve.ECgFp5Scalar, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var buf [32]byte
+	if _, err := io.ReadFull(rand, buf[:]); err != nil {
+		return curve.ZERO, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	secretLimbs := secret.SplitTo32BitLimbs()
+	input := make([]g.GoldilocksField, 0, 1+8+10+1)
+	input = append(input, NONCE_TAG)
+	for i := 0; i < len(buf); i += 4 {
+		input = append(input, g.GoldilocksField(binary.LittleEndian.Uint32(buf[i:])))
+	}
+	input = append(input, secretLimbs[:]...)
+	input = append(input, g.GoldilocksField(j))
+	return curve.HashToScalar(input), nil
+}
+
+// Commitment returns the public commitment to the nonces.
+func (n *SigningNonces) Commitment() SigningCommitment {
+	return n.commitment
+}
+
+// SigningPackage is the set of commitments chosen by the coordinator,
+// along with the hashed message to sign.
+type SigningPackage struct {
+	commitments []SigningCommitment
+	hashedMsg   gFp5.Element
+}
+
+// NewSigningPackage builds a signing package; the commitments must have
+// distinct identifiers.
+func NewSigningPackage(commitments []SigningCommitment, hashedMsg gFp5.Element) (*SigningPackage, error) {
+	if len(commitments) == 0 {
+		return nil, errors.New("no commitments")
+	}
+	ids := make([]uint32, len(commitments))
+	for i := range commitments {
+		ids[i] = commitments[i].ID
+	}
+	if _, err := sortedIDs(ids); err != nil {
+		return nil, err
+	}
+
+	sorted := append([]SigningCommitment(nil), commitments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return &SigningPackage{commitments: sorted, hashedMsg: hashedMsg}, nil
+}
+
+// Commitments returns the commitments, sorted by identifier.
+func (sp *SigningPackage) Commitments() []SigningCommitment {
+	return append([]SigningCommitment(nil), sp.commitments...)
+}
+
+// HashedMsg returns the hashed message to sign.
+func (sp *SigningPackage) HashedMsg() gFp5.Element {
+	return sp.hashedMsg
+}
+
+// Values derived from a signing package and the group public key.
+type signingState struct {
+	ids []uint32
+	// Binding factor rho_i of each signer, in the order of ids.
+	rho []curve.ECgFp5Scalar
+	// Challenge.
+	e curve.ECgFp5Scalar
+}
+
+// Compute the binding factors, the group commitment and the challenge:
+//
+//	L = H(COMMITMENT_LIST_TAG || len || (i || D_i || E_i)...)
+//	rho_i = HashToScalar(BINDING_FACTOR_TAG || X || H(m) || L || i)
+//	R = \sum_i (D_i + rho_i*E_i)
+//	e = H(R || H(m))
+func (sp *SigningPackage) state(groupPublicKey curve.ECgFp5Point) signingState {
+	n := len(sp.commitments)
+	listInput := make([]g.GoldilocksField, 0, 2+11*n)
+	listInput = append(listInput, COMMITMENT_LIST_TAG, g.GoldilocksField(uint64(n)))
+	for _, c := range sp.commitments {
+		listInput = append(listInput, g.GoldilocksField(uint64(c.ID)))
+		listInput = appendElement(listInput, c.Hiding.Encode())
+		listInput = appendElement(listInput, c.Binding.Encode())
+	}
+	list := p2.HashNoPad(listInput)
+
+	prefix := make([]g.GoldilocksField, 0, 1+5+5+4+1)
+	prefix = append(prefix, BINDING_FACTOR_TAG)
+	prefix = appendElement(prefix, groupPublicKey.Encode())
+	prefix = appendElement(prefix, sp.hashedMsg)
+	prefix = append(prefix, list[:]...)
+	prefix = append(prefix, 0)
+
+	st := signingState{
+		ids: make([]uint32, n),
+		rho: make([]curve.ECgFp5Scalar, n),
+		e:   curve.ZERO,
+	}
+	points := make([]curve.ECgFp5Point, 0, 2*n)
+	scalars := make([]curve.ECgFp5Scalar, 0, 2*n)
+	for i, c := range sp.commitments {
+		st.ids[i] = c.ID
+		prefix[len(prefix)-1] = g.GoldilocksField(uint64(c.ID))
+		st.rho[i] = curve.HashToScalar(prefix)
+		points = append(points, c.Hiding, c.Binding)
+		scalars = append(scalars, curve.ONE, st.rho[i])
+	}
+	r := curve.MultiScalarMulVarTime(points, scalars)
+	st.e = schnorr.HashChallenge(r.Encode(), sp.hashedMsg)
+	return st
+}
+
+// Index of a signer in the signing package, or -1.
+func (st *signingState) index(id uint32) int {
+	i := sort.Search(len(st.ids), func(i int) bool { return st.ids[i] >= id })
+	if i < len(st.ids) && st.ids[i] == id {
+		return i
+	}
+	return -1
+}
+
+// Sign computes the signature share of the participant:
+//
+//	z_i = d_i + rho_i*e_i - lambda_i*e*x_i
+//
+// The signing package must contain at least threshold commitments,
+// including the one of these nonces. The nonces are erased, so that they
+// cannot be used again.
+func Sign(kp *KeyPackage, nonces *SigningNonces, sp *SigningPackage) (SignatureShare, error) {
+	if len(sp.commitments) < kp.Threshold {
+		return SignatureShare{}, errors.New("not enough signers")
+	}
+	if nonces.hiding.Equals(curve.ZERO) && nonces.binding.Equals(curve.ZERO) {
+		return SignatureShare{}, errors.New("nonces have already been used")
+	}
+	if nonces.commitment.ID != kp.ID {
+		return SignatureShare{}, errors.New("nonces do not belong to this participant")
+	}
+
+	st := sp.state(kp.GroupPublicKey)
+	i := st.index(kp.ID)
+	if i < 0 {
+		return SignatureShare{}, errors.New("participant is not in the signing package")
+	}
+	c := sp.commitments[i]
+	if !c.Hiding.Equals(nonces.commitment.Hiding) || !c.Binding.Equals(nonces.commitment.Binding) {
+		return SignatureShare{}, errors.New("signing package does not contain the commitment of these nonces")
+	}
+	lambda, err := vss.LagrangeCoefficient(kp.ID, st.ids)
+	if err != nil {
+		return SignatureShare{}, err
+	}
+
+	d, e := nonces.hiding, nonces.binding
+	nonces.hiding, nonces.binding = curve.ZERO, curve.ZERO
+
+	k := d.Add(st.rho[i].Mul(e))
+	return SignatureShare{ID: kp.ID, Z: k.Sub(lambda.Mul(st.e).Mul(kp.SecretShare))}, nil
+}
+
+// VerifySignatureShare checks the signature share of a signer:
+//
+//	z_i*G + (lambda_i*e)*X_i = D_i + rho_i*E_i
+func VerifySignatureShare(pkp *PublicKeyPackage, sp *SigningPackage, share SignatureShare) bool {
+	st := sp.state(pkp.GroupPublicKey)
+	return verifyShare(pkp, sp, &st, share)
+}
+
+func verifyShare(pkp *PublicKeyPackage, sp *SigningPackage, st *signingState, share SignatureShare) bool {
+	if !share.Z.IsCanonical() {
+		return false
+	}
+	i := st.index(share.ID)
+	if i < 0 {
+		return false
+	}
+	vs, ok := pkp.VerificationShares[share.ID]
+	if !ok {
+		return false
+	}
+	lambda, err := vss.LagrangeCoefficient(share.ID, st.ids)
+	if err != nil {
+		return false
+	}
+
+	c := sp.commitments[i]
+	lhs := vs.MulAddGenVarTime(share.Z, lambda.Mul(st.e))
+	rhs := c.Hiding.Add(c.Binding.Mul(st.rho[i]))
+	return lhs.Equals(rhs)
+}
+
+// Aggregate combines the signature shares of all signers of the signing
+// package into a Schnorr signature for the group public key. If the
+// resulting signature is invalid, then each share is verified, and a
+// *CulpritError lists the signers whose shares are invalid.
+func Aggregate(pkp *PublicKeyPackage, sp *SigningPackage, shares []SignatureShare) (schnorr.Signature, error) {
+	if len(sp.commitments) < pkp.Threshold {
+		return schnorr.ZERO_SIG, errors.New("not enough signers")
+	}
+	st := sp.state(pkp.GroupPublicKey)
+
+	byID := make(map[uint32]SignatureShare, len(shares))
+	for _, s := range shares {
+		if st.index(s.ID) < 0 {
+			return schnorr.ZERO_SIG, fmt.Errorf("participant %d is not in the signing package", s.ID)
+		}
+		if _, ok := byID[s.ID]; ok {
+			return schnorr.ZERO_SIG, fmt.Errorf("duplicate share for participant %d", s.ID)
+		}
+		if !s.Z.IsCanonical() {
+			return schnorr.ZERO_SIG, &CulpritError{Culprits: []uint32{s.ID}, Reason: "non-canonical signature share"}
+		}
+		byID[s.ID] = s
+	}
+	var missing []uint32
+	for _, id := range st.ids {
+		if _, ok := byID[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return schnorr.ZERO_SIG, &CulpritError{Culprits: missing, Reason: "missing signature shares"}
+	}
+
+	z := curve.ZERO
+	for _, s := range shares {
+		z = z.Add(s.Z)
+	}
+	sig := schnorr.Signature{S: z, E: st.e}
+	if schnorr.IsSchnorrSignatureValid(pkp.PublicKey(), sp.hashedMsg, sig) {
+		return sig, nil
+	}
+
+	var culprits []uint32
+	for _, id := range st.ids {
+		if !verifyShare(pkp, sp, &st, byID[id]) {
+			culprits = append(culprits, id)
+		}
+	}
+	return schnorr.ZERO_SIG, &CulpritError{Culprits: culprits, Reason: "invalid signature shares"}
+}
+
+// Append the canonical limbs of an Fp5 element.
+func appendElement(input []g.GoldilocksField, e gFp5.Element) []g.GoldilocksField {
+	for _, v := range e.ToUint64Array() {
+		input = append(input, g.GoldilocksField(v))
+	}
+	return input
+}
@@ -0,0 +1,172 @@
+// Package musig2 implements MuSig2 n-of-n multi-signatures over the
+// ECgFp5 group (Nick, Ruffing and Seurin, "MuSig2: Simple Two-Round Schnorr
+// Multi-Signatures", CRYPTO 2021), following the structure of BIP 327.
+//
+// The output is a standard Schnorr signature (see package
+// signature/schnorr) for the aggregate public key: it is accepted by the
+// unmodified IsSchnorrSignatureValid().
+//
+// PROTOCOL:
+//
+//  1. Key aggregation: all signers agree on the ordered list of public
+//     keys, and compute the aggregate key with AggregatePublicKeys().
+//  2. Nonce round: each signer runs NonceGen(), keeps the secret nonce,
+//     and sends the public nonce to the other signers (or to an
+//     aggregator, which runs AggregateNonces()).
+//  3. Signing round: with the aggregate nonce and the hashed message, each
+//     signer builds the Session and computes a partial signature with
+//     Session.Sign(); partial signatures can be checked individually with
+//     Session.VerifyPartial(), and are combined with Session.Aggregate().
+//
+// The signature equation is the one of package signature/schnorr: with
+// coefficients a_i and key X = \sum_i a_i*X_i, nonce coefficient b and
+// R = R_1 + b*R_2 (aggregate nonces), the challenge is e = H(R || H(m)),
+// and each signer contributes s_i = k_i1 + b*k_i2 - e*a_i*x_i, so that
+// s = \sum_i s_i fulfills s*G + e*X = R.
+//
+// SECURITY CONSIDERATIONS:
+//
+// A secret nonce must never be used for two signatures, or the secret
+// key leaks. Session.Sign() erases the secret nonce it uses; callers which
+// serialize secret nonces (e.g. to hand them over between processes) must
+// make sure that each serialized nonce is used at most once.
+package musig2
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+// Domain separation tags, as ASCII strings read as little-endian 64-bit
+// integers.
+const (
+	// "musig2kl": hash of the public key list.
+	KEYAGG_LIST_TAG = g.GoldilocksField(0x6c6b32676973756d)
+	// "musig2kc": key aggregation coefficients.
+	KEYAGG_COEF_TAG = g.GoldilocksField(0x636b32676973756d)
+	// "musig2nc": nonce coefficient.
+	NONCE_COEF_TAG = g.GoldilocksField(0x636e32676973756d)
+	// "musig2ng": nonce generation.
+	NONCE_GEN_TAG = g.GoldilocksField(0x676e32676973756d)
+)
+
+// KeyAggContext holds an ordered list of public keys, with their
+// aggregation coefficients and the aggregate public key.
+//
+// The coefficient of key X_i is a_i = H(KEYAGG_COEF_TAG || L || X_i), with
+// L = H(KEYAGG_LIST_TAG || len || X_1 || ... || X_n); the aggregate key is
+// X = \sum_i a_i*X_i. It depends on the order of the keys: signers which
+// do not have a natural order can use SortPublicKeys().
+type KeyAggContext struct {
+	pubKeys  []gFp5.Element
+	points   []curve.ECgFp5Point
+	coefs    []curve.ECgFp5Scalar
+	aggPoint curve.ECgFp5Point
+}
+
+// AggregatePublicKeys computes the key aggregation context for the
+// provided public keys. An error is returned if the list is empty, if a
+// key is invalid or appears twice, or if the aggregate key is the neutral.
+func AggregatePublicKeys(pubKeys []gFp5.Element) (*KeyAggContext, error) {
+	n := len(pubKeys)
+	if n == 0 {
+		return nil, errors.New("no public keys")
+	}
+
+	ctx := &KeyAggContext{
+		pubKeys:  make([]gFp5.Element, n),
+		points:   make([]curve.ECgFp5Point, n),
+		coefs:    make([]curve.ECgFp5Scalar, n),
+		aggPoint: curve.NEUTRAL_ECgFp5Point,
+	}
+	listInput := make([]g.GoldilocksField, 0, 2+5*n)
+	listInput = append(listInput, KEYAGG_LIST_TAG, g.GoldilocksField(uint64(n)))
+	for i, pk := range pubKeys {
+		point, ok := curve.Decode(pk)
+		if !ok || point.IsNeutral() {
+			return nil, errors.New("invalid public key")
+		}
+		// Use the canonical encoding, so that the coefficients do not
+		// depend on the representation of the input elements.
+		ctx.pubKeys[i] = point.Encode()
+		ctx.points[i] = point
+		for j := 0; j < i; j++ {
+			if gFp5.Equals(ctx.pubKeys[j], ctx.pubKeys[i]) {
+				return nil, errors.New("duplicate public key")
+			}
+		}
+		listInput = append(listInput, canonicalLimbs(ctx.pubKeys[i])...)
+	}
+	list := p2.HashNoPad(listInput)
+
+	for i := range ctx.pubKeys {
+		input := make([]g.GoldilocksField, 0, 1+4+5)
+		input = append(input, KEYAGG_COEF_TAG)
+		input = append(input, list[:]...)
+		input = append(input, canonicalLimbs(ctx.pubKeys[i])...)
+		ctx.coefs[i] = curve.HashToScalar(input)
+	}
+	ctx.aggPoint = curve.MultiScalarMulVarTime(ctx.points, ctx.coefs)
+	if ctx.aggPoint.IsNeutral() {
+		return nil, errors.New("aggregate public key is the neutral")
+	}
+
+	return ctx, nil
+}
+
+// PublicKey returns the aggregate public key, which verifies the final
+// signature.
+func (c *KeyAggContext) PublicKey() gFp5.Element {
+	return c.aggPoint.Encode()
+}
+
+// PublicKeys returns the (canonically encoded) list of public keys, in
+// aggregation order.
+func (c *KeyAggContext) PublicKeys() []gFp5.Element {
+	return append([]gFp5.Element(nil), c.pubKeys...)
+}
+
+// Coefficient returns the aggregation coefficient of a public key, and
+// false if the key is not in the list.
+func (c *KeyAggContext) Coefficient(pk gFp5.Element) (curve.ECgFp5Scalar, bool) {
+	i := c.index(pk)
+	if i < 0 {
+		return curve.ZERO, false
+	}
+	return c.coefs[i], true
+}
+
+// Index of a public key in the list, or -1.
+func (c *KeyAggContext) index(pk gFp5.Element) int {
+	for i := range c.pubKeys {
+		if gFp5.Equals(c.pubKeys[i], pk) {
+			return i
+		}
+	}
+	return -1
+}
+
+// SortPublicKeys returns a copy of the public keys, sorted by their
+// byte encoding.
+func SortPublicKeys(pubKeys []gFp5.Element) []gFp5.Element {
+	res := append([]gFp5.Element(nil), pubKeys...)
+	sort.Slice(res, func(i, j int) bool {
+		return bytes.Compare(res[i].ToLittleEndianBytes(), res[j].ToLittleEndianBytes()) < 0
+	})
+	return res
+}
+
+// Limbs of an Fp5 element in canonical form, for hashing.
+func canonicalLimbs(e gFp5.Element) []g.GoldilocksField {
+	res := make([]g.GoldilocksField, 5)
+	for i := range res {
+		res[i] = g.GoldilocksField(e[i].ToCanonicalUint64())
+	}
+	return res
+}
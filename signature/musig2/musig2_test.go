@@ -0,0 +1,220 @@
+package musig2
+
+import (
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+)
+
+type signer struct {
+	sk       *schnorr.PrivateKey
+	secNonce *SecretNonce
+	pubNonce PublicNonce
+}
+
+func makeSigners(t *testing.T, n int) ([]signer, *KeyAggContext) {
+	signers := make([]signer, n)
+	pubKeys := make([]gFp5.Element, n)
+	for i := range signers {
+		sk, err := schnorr.GenerateKey(nil)
+		if err != nil {
+			t.Fatalf("GenerateKey failed: %v", err)
+		}
+		signers[i].sk = sk
+		pubKeys[i] = sk.PublicKey().Element()
+	}
+	ctx, err := AggregatePublicKeys(pubKeys)
+	if err != nil {
+		t.Fatalf("AggregatePublicKeys failed: %v", err)
+	}
+	return signers, ctx
+}
+
+// Run the nonce round, and return the session.
+func runNonceRound(t *testing.T, signers []signer, ctx *KeyAggContext, hashedMsg gFp5.Element) *Session {
+	pubNonces := make([]PublicNonce, len(signers))
+	for i := range signers {
+		secNonce, pubNonce, err := NonceGen(signers[i].sk, ctx.PublicKey(), hashedMsg, nil)
+		if err != nil {
+			t.Fatalf("NonceGen failed: %v", err)
+		}
+		signers[i].secNonce, signers[i].pubNonce = secNonce, pubNonce
+		pubNonces[i] = pubNonce
+	}
+	aggNonce, err := AggregateNonces(pubNonces)
+	if err != nil {
+		t.Fatalf("AggregateNonces failed: %v", err)
+	}
+	return NewSession(ctx, aggNonce, hashedMsg)
+}
+
+func TestMuSig2(t *testing.T) {
+	for _, n := range []int{1, 2, 5} {
+		signers, ctx := makeSigners(t, n)
+		hashedMsg := gFp5.Sample()
+		session := runNonceRound(t, signers, ctx, hashedMsg)
+
+		partials := make([]curve.ECgFp5Scalar, n)
+		for i := range signers {
+			partial, err := session.Sign(signers[i].secNonce, signers[i].sk)
+			if err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+			if !session.VerifyPartial(signers[i].pubNonce, signers[i].sk.PublicKey().Element(), partial) {
+				t.Fatalf("partial signature %d is invalid", i)
+			}
+			partials[i] = partial
+		}
+
+		sig := session.Aggregate(partials)
+		if !schnorr.IsSchnorrSignatureValid(ctx.PublicKey(), hashedMsg, sig) {
+			t.Fatalf("n = %d: aggregate signature is invalid", n)
+		}
+		if schnorr.IsSchnorrSignatureValid(ctx.PublicKey(), gFp5.Sample(), sig) {
+			t.Fatalf("n = %d: aggregate signature should not verify another message", n)
+		}
+		if n > 1 && schnorr.IsSchnorrSignatureValid(ctx.PublicKey(), hashedMsg, session.Aggregate(partials[1:])) {
+			t.Fatalf("n = %d: signature with a missing partial should be invalid", n)
+		}
+	}
+}
+
+func TestKeyAggregation(t *testing.T) {
+	signers, ctx := makeSigners(t, 3)
+	pubKeys := ctx.PublicKeys()
+
+	// The aggregate key is \sum_i a_i*X_i, and depends on the order.
+	expected := curve.NEUTRAL_ECgFp5Point
+	for _, s := range signers {
+		a, ok := ctx.Coefficient(s.sk.PublicKey().Element())
+		if !ok {
+			t.Fatalf("missing coefficient")
+		}
+		expected = expected.Add(s.sk.PublicKey().Point().Mul(a))
+	}
+	if !gFp5.Equals(expected.Encode(), ctx.PublicKey()) {
+		t.Fatalf("wrong aggregate key")
+	}
+	reordered, err := AggregatePublicKeys([]gFp5.Element{pubKeys[1], pubKeys[0], pubKeys[2]})
+	if err != nil {
+		t.Fatalf("AggregatePublicKeys failed: %v", err)
+	}
+	if gFp5.Equals(reordered.PublicKey(), ctx.PublicKey()) {
+		t.Fatalf("aggregate key should depend on the order of the keys")
+	}
+	sorted1, _ := AggregatePublicKeys(SortPublicKeys(pubKeys))
+	sorted2, _ := AggregatePublicKeys(SortPublicKeys(reordered.PublicKeys()))
+	if !gFp5.Equals(sorted1.PublicKey(), sorted2.PublicKey()) {
+		t.Fatalf("sorted keys should yield the same aggregate key")
+	}
+	if _, ok := ctx.Coefficient(gFp5.FP5_ZERO); ok {
+		t.Fatalf("unexpected coefficient for a key outside of the list")
+	}
+
+	// Invalid lists.
+	if _, err := AggregatePublicKeys(nil); err == nil {
+		t.Fatalf("empty list should be rejected")
+	}
+	if _, err := AggregatePublicKeys([]gFp5.Element{pubKeys[0], pubKeys[1], pubKeys[0]}); err == nil {
+		t.Fatalf("duplicate keys should be rejected")
+	}
+	if _, err := AggregatePublicKeys([]gFp5.Element{pubKeys[0], gFp5.FP5_ZERO}); err == nil {
+		t.Fatalf("the neutral should be rejected")
+	}
+}
+
+func TestPartialSignatureVerification(t *testing.T) {
+	signers, ctx := makeSigners(t, 3)
+	hashedMsg := gFp5.Sample()
+	session := runNonceRound(t, signers, ctx, hashedMsg)
+
+	partials := make([]curve.ECgFp5Scalar, len(signers))
+	for i := range signers {
+		partials[i], _ = session.Sign(signers[i].secNonce, signers[i].sk)
+	}
+
+	// A wrong partial signature is attributed to its signer only.
+	bad := partials[1].Add(curve.ONE)
+	for i := range signers {
+		p := partials[i]
+		if i == 1 {
+			p = bad
+		}
+		if session.VerifyPartial(signers[i].pubNonce, signers[i].sk.PublicKey().Element(), p) != (i != 1) {
+			t.Fatalf("wrong partial verification result for signer %d", i)
+		}
+	}
+	if session.VerifyPartial(signers[0].pubNonce, signers[1].sk.PublicKey().Element(), partials[0]) {
+		t.Fatalf("partial signature should not verify with another key")
+	}
+	if session.VerifyPartial(signers[0].pubNonce, signers[0].sk.PublicKey().Element(), partials[0].AddInner(curve.N)) {
+		t.Fatalf("non-canonical partial signature should be rejected")
+	}
+}
+
+func TestSignErrors(t *testing.T) {
+	signers, ctx := makeSigners(t, 2)
+	session := runNonceRound(t, signers, ctx, gFp5.Sample())
+
+	if _, err := session.Sign(signers[0].secNonce, signers[1].sk); err == nil {
+		t.Fatalf("Sign should reject a nonce of another signer")
+	}
+	outsider, _ := schnorr.GenerateKey(nil)
+	secNonce, _, _ := NonceGen(outsider, ctx.PublicKey(), gFp5.FP5_ZERO, nil)
+	if _, err := session.Sign(secNonce, outsider); err == nil {
+		t.Fatalf("Sign should reject a signer outside of the key aggregation")
+	}
+	if _, err := session.Sign(signers[0].secNonce, signers[0].sk); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := session.Sign(signers[0].secNonce, signers[0].sk); err == nil {
+		t.Fatalf("Sign should refuse to reuse a nonce")
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	signers, ctx := makeSigners(t, 3)
+	hashedMsg := gFp5.Sample()
+	session := runNonceRound(t, signers, ctx, hashedMsg)
+
+	// Each signer restores the session and its secret nonce, as a
+	// separate process would.
+	sessionBytes := session.Bytes()
+	partials := make([]curve.ECgFp5Scalar, len(signers))
+	for i := range signers {
+		restored, err := SessionFromBytes(sessionBytes)
+		if err != nil {
+			t.Fatalf("SessionFromBytes failed: %v", err)
+		}
+		secNonce, err := SecretNonceFromBytes(signers[i].secNonce.Bytes())
+		if err != nil {
+			t.Fatalf("SecretNonceFromBytes failed: %v", err)
+		}
+		pubNonce, err := PublicNonceFromBytes(signers[i].pubNonce.Bytes())
+		if err != nil || !pubNonce.R1.Equals(signers[i].pubNonce.R1) || !pubNonce.R2.Equals(signers[i].pubNonce.R2) {
+			t.Fatalf("public nonce does not round-trip: %v", err)
+		}
+		partials[i], err = restored.Sign(secNonce, signers[i].sk)
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		if !session.VerifyPartial(pubNonce, signers[i].sk.PublicKey().Element(), partials[i]) {
+			t.Fatalf("partial signature %d is invalid", i)
+		}
+	}
+	if !schnorr.IsSchnorrSignatureValid(ctx.PublicKey(), hashedMsg, session.Aggregate(partials)) {
+		t.Fatalf("aggregate signature is invalid")
+	}
+
+	if _, err := SessionFromBytes(sessionBytes[:len(sessionBytes)-1]); err == nil {
+		t.Fatalf("SessionFromBytes should reject truncated inputs")
+	}
+	if _, err := SecretNonceFromBytes(make([]byte, SecretNonceSize-1)); err == nil {
+		t.Fatalf("SecretNonceFromBytes should reject short inputs")
+	}
+	if _, err := PublicNonceFromBytes(make([]byte, PublicNonceSize+1)); err == nil {
+		t.Fatalf("PublicNonceFromBytes should reject long inputs")
+	}
+}
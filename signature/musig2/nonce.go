@@ -0,0 +1,161 @@
+package musig2
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+)
+
+// Length of encoded nonces, in bytes.
+const (
+	SecretNonceSize = 120
+	PublicNonceSize = 80
+)
+
+// Number of random bytes mixed into each nonce pair.
+const nonceRandBytes = 32
+
+// SecretNonce is the secret part of a signer's nonce pair (k_1, k_2),
+// bound to the signer's public key. It must be used for at most one
+// signature; Session.Sign() erases it.
+type SecretNonce struct {
+	k1, k2 curve.ECgFp5Scalar
+	pk     gFp5.Element
+}
+
+// PublicNonce is the public part of a nonce pair: (R_1, R_2) =
+// (k_1*G, k_2*G). The aggregate nonce of a session has the same type.
+type PublicNonce struct {
+	R1, R2 curve.ECgFp5Point
+}
+
+var NEUTRAL_NONCE = PublicNonce{
+	R1: curve.NEUTRAL_ECgFp5Point,
+	R2: curve.NEUTRAL_ECgFp5Point,
+}
+
+// NonceGen generates a fresh nonce pair for the signer, with randomness
+// from rand (or crypto/rand if rand is nil). The aggregate public key and
+// the hashed message are optional (zero if not known yet): as the secret
+// key, they are mixed into the derivation, which protects against a
+// partially broken RNG. Each nonce is
+//
+//	k_j = HashToScalar(NONCE_GEN_TAG || rand || sk || pk || aggPk
+//	                   || hashedMsg || j)
+//
+// with the 32 random bytes as eight 32-bit elements.
+func NonceGen(sk *schnorr.PrivateKey, aggPk, hashedMsg gFp5.Element, rand io.Reader) (*SecretNonce, PublicNonce, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var buf [nonceRandBytes]byte
+	if _, err := io.ReadFull(rand, buf[:]); err != nil {
+		return nil, NEUTRAL_NONCE, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	skLimbs := sk.Scalar().SplitTo32BitLimbs()
+	pk := sk.PublicKey().Element()
+	input := make([]g.GoldilocksField, 0, 1+nonceRandBytes/4+10+5+5+5+1)
+	input = append(input, NONCE_GEN_TAG)
+	for i := 0; i < nonceRandBytes; i += 4 {
+		input = append(input, g.GoldilocksField(binary.LittleEndian.Uint32(buf[i:])))
+	}
+	input = append(input, skLimbs[:]...)
+	input = append(input, canonicalLimbs(pk)...)
+	input = append(input, canonicalLimbs(aggPk)...)
+	input = append(input, canonicalLimbs(hashedMsg)...)
+
+	input = append(input, 1)
+	k1 := curve.HashToScalar(input)
+	input[len(input)-1] = 2
+	k2 := curve.HashToScalar(input)
+
+	secNonce := &SecretNonce{k1: k1, k2: k2, pk: pk}
+	return secNonce, secNonce.Public(), nil
+}
+
+// Public returns the public nonce of this secret nonce.
+func (n *SecretNonce) Public() PublicNonce {
+	return PublicNonce{
+		R1: curve.MulGen(n.k1),
+		R2: curve.MulGen(n.k2),
+	}
+}
+
+// Bytes returns the 120-byte encoding k_1 || k_2 || pk.
+//
+// WARNING: the encoding contains secret values. A serialized nonce must
+// be used for at most one signature: restoring it twice leaks the
+// secret key.
+func (n *SecretNonce) Bytes() []byte {
+	res := make([]byte, 0, SecretNonceSize)
+	res = append(res, n.k1.ToLittleEndianBytes()...)
+	res = append(res, n.k2.ToLittleEndianBytes()...)
+	res = append(res, n.pk.ToLittleEndianBytes()...)
+	return res
+}
+
+// SecretNonceFromBytes decodes a secret nonce from its encoding.
+func SecretNonceFromBytes(b []byte) (*SecretNonce, error) {
+	if len(b) != SecretNonceSize {
+		return nil, errors.New("invalid secret nonce length, must be 120 bytes")
+	}
+	k1, err := curve.ScalarFromCanonicalBytes(b[:40])
+	if err != nil {
+		return nil, fmt.Errorf("invalid k1: %w", err)
+	}
+	k2, err := curve.ScalarFromCanonicalBytes(b[40:80])
+	if err != nil {
+		return nil, fmt.Errorf("invalid k2: %w", err)
+	}
+	pk, err := curve.PointFromBytes(b[80:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return &SecretNonce{k1: k1, k2: k2, pk: pk.Encode()}, nil
+}
+
+// Bytes returns the 80-byte encoding R_1 || R_2.
+func (n PublicNonce) Bytes() []byte {
+	res := make([]byte, 0, PublicNonceSize)
+	res = append(res, n.R1.ToBytes()...)
+	res = append(res, n.R2.ToBytes()...)
+	return res
+}
+
+// PublicNonceFromBytes decodes a public (or aggregate) nonce from its
+// encoding.
+func PublicNonceFromBytes(b []byte) (PublicNonce, error) {
+	if len(b) != PublicNonceSize {
+		return NEUTRAL_NONCE, errors.New("invalid public nonce length, must be 80 bytes")
+	}
+	r1, err := curve.PointFromBytes(b[:40])
+	if err != nil {
+		return NEUTRAL_NONCE, fmt.Errorf("invalid R1: %w", err)
+	}
+	r2, err := curve.PointFromBytes(b[40:])
+	if err != nil {
+		return NEUTRAL_NONCE, fmt.Errorf("invalid R2: %w", err)
+	}
+	return PublicNonce{R1: r1, R2: r2}, nil
+}
+
+// AggregateNonces sums the public nonces of all signers.
+func AggregateNonces(nonces []PublicNonce) (PublicNonce, error) {
+	if len(nonces) == 0 {
+		return NEUTRAL_NONCE, errors.New("no nonces")
+	}
+	agg := nonces[0]
+	for _, n := range nonces[1:] {
+		agg.R1 = agg.R1.Add(n.R1)
+		agg.R2 = agg.R2.Add(n.R2)
+	}
+	return agg, nil
+}
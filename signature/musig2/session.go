@@ -0,0 +1,161 @@
+package musig2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+)
+
+// Session is the signing round state: the key aggregation context, the
+// aggregate nonce and the hashed message, along with the values derived
+// from them:
+//
+//	b = H(NONCE_COEF_TAG || X || R_1 || R_2 || H(m))
+//	R = R_1 + b*R_2
+//	e = H(R || H(m))
+//
+// A session holds no secret, and all signers derive the same one.
+type Session struct {
+	ctx       *KeyAggContext
+	aggNonce  PublicNonce
+	hashedMsg gFp5.Element
+	b, e      curve.ECgFp5Scalar
+}
+
+// NewSession builds the session for signing the hashed message with the
+// provided aggregate nonce.
+func NewSession(ctx *KeyAggContext, aggNonce PublicNonce, hashedMsg gFp5.Element) *Session {
+	input := make([]g.GoldilocksField, 0, 1+4*5)
+	input = append(input, NONCE_COEF_TAG)
+	input = append(input, canonicalLimbs(ctx.PublicKey())...)
+	input = append(input, canonicalLimbs(aggNonce.R1.Encode())...)
+	input = append(input, canonicalLimbs(aggNonce.R2.Encode())...)
+	input = append(input, canonicalLimbs(hashedMsg)...)
+	b := curve.HashToScalar(input)
+
+	r := aggNonce.R1.Add(aggNonce.R2.Mul(b))
+	return &Session{
+		ctx:       ctx,
+		aggNonce:  aggNonce,
+		hashedMsg: hashedMsg,
+		b:         b,
+		e:         schnorr.HashChallenge(r.Encode(), hashedMsg),
+	}
+}
+
+// Sign computes the partial signature of a signer:
+//
+//	s_i = k_1 + b*k_2 - e*a_i*x_i
+//
+// The secret nonce must have been generated for this secret key; it is
+// erased, so that it cannot be used again.
+func (s *Session) Sign(secNonce *SecretNonce, sk *schnorr.PrivateKey) (curve.ECgFp5Scalar, error) {
+	if secNonce.k1.Equals(curve.ZERO) && secNonce.k2.Equals(curve.ZERO) {
+		return curve.ZERO, errors.New("secret nonce has already been used")
+	}
+	pk := sk.PublicKey().Element()
+	if !gFp5.Equals(secNonce.pk, pk) {
+		return curve.ZERO, errors.New("secret nonce does not belong to this signer")
+	}
+	a, ok := s.ctx.Coefficient(pk)
+	if !ok {
+		return curve.ZERO, errors.New("signer is not part of the key aggregation")
+	}
+
+	k1, k2 := secNonce.k1, secNonce.k2
+	secNonce.k1, secNonce.k2 = curve.ZERO, curve.ZERO
+
+	k := k1.Add(s.b.Mul(k2))
+	return k.Sub(s.e.Mul(a).Mul(sk.Scalar())), nil
+}
+
+// VerifyPartial checks the partial signature of the signer with public
+// key pk and public nonce pubNonce:
+//
+//	s_i*G + (e*a_i)*X_i = R_i1 + b*R_i2
+//
+// This allows an aggregator to identify the signers which sent invalid
+// partial signatures.
+func (s *Session) VerifyPartial(pubNonce PublicNonce, pk gFp5.Element, partial curve.ECgFp5Scalar) bool {
+	if !partial.IsCanonical() {
+		return false
+	}
+	i := s.ctx.index(pk)
+	if i < 0 {
+		return false
+	}
+
+	lhs := s.ctx.points[i].MulAddGenVarTime(partial, s.e.Mul(s.ctx.coefs[i]))
+	rhs := pubNonce.R1.Add(pubNonce.R2.Mul(s.b))
+	return lhs.Equals(rhs)
+}
+
+// Aggregate sums the partial signatures of all signers into a Schnorr
+// signature for the aggregate public key.
+func (s *Session) Aggregate(partials []curve.ECgFp5Scalar) schnorr.Signature {
+	sum := curve.ZERO
+	for _, p := range partials {
+		sum = sum.Add(p)
+	}
+	return schnorr.Signature{S: sum, E: s.e}
+}
+
+// Bytes encodes the session, so that it can be restored by another
+// process:
+//
+//	n (4 bytes, little-endian) || X_1 || ... || X_n || R_1 || R_2 || H(m)
+//
+// with all elements in their 40-byte encoding.
+func (s *Session) Bytes() []byte {
+	n := len(s.ctx.pubKeys)
+	res := make([]byte, 4, 4+40*n+PublicNonceSize+40)
+	binary.LittleEndian.PutUint32(res, uint32(n)) //nolint:gosec
+	for _, pk := range s.ctx.pubKeys {
+		res = append(res, pk.ToLittleEndianBytes()...)
+	}
+	res = append(res, s.aggNonce.Bytes()...)
+	res = append(res, s.hashedMsg.ToLittleEndianBytes()...)
+	return res
+}
+
+// SessionFromBytes restores a session from its encoding.
+func SessionFromBytes(b []byte) (*Session, error) {
+	if len(b) < 4 {
+		return nil, errors.New("invalid session length")
+	}
+	n := uint64(binary.LittleEndian.Uint32(b))
+	if uint64(len(b)) != 4+40*n+PublicNonceSize+40 {
+		return nil, errors.New("invalid session length")
+	}
+
+	pubKeys := make([]gFp5.Element, n)
+	off := 4
+	for i := range pubKeys {
+		pk, err := curve.PointFromBytes(b[off : off+40])
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %d: %w", i, err)
+		}
+		pubKeys[i] = pk.Encode()
+		off += 40
+	}
+	ctx, err := AggregatePublicKeys(pubKeys)
+	if err != nil {
+		return nil, err
+	}
+	aggNonce, err := PublicNonceFromBytes(b[off : off+PublicNonceSize])
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregate nonce: %w", err)
+	}
+	off += PublicNonceSize
+	hashedMsg, err := gFp5.FromCanonicalLittleEndianBytes(b[off:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hashed message: %w", err)
+	}
+
+	return NewSession(ctx, aggNonce, hashedMsg), nil
+}
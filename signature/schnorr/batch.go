@@ -0,0 +1,207 @@
+package signature
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+// CommittedSignature is an alternate encoding of a Schnorr signature which
+// carries the commitment point r = k*G (encoded as an Fp5 element) instead
+// of the challenge e = H(r || H(m)).
+//
+// Given the public key and the hashed message, both encodings hold the same
+// information and convert one-to-one (see Signature.ToCommitted() and
+// CommittedSignature.ToSignature()). Since r is known, the verification
+// equation s*G + e*pk = r can be checked directly, which allows
+// lattice-reduced single verification and batch verification.
+type CommittedSignature struct {
+	R gFp5.Element
+	S curve.ECgFp5Scalar
+}
+
+var ZERO_COMMITTED_SIG = CommittedSignature{
+	R: gFp5.FP5_ZERO,
+	S: curve.ZERO,
+}
+
+// ToCommitted converts a signature into its committed form, by recomputing
+// r = s*G + e*pk. An error is returned if the signature is not valid for
+// this public key and message, since r would then be meaningless.
+func (s Signature) ToCommitted(pubKey, hashedMsg gFp5.Element) (CommittedSignature, error) {
+	if !s.IsCanonical() {
+		return ZERO_COMMITTED_SIG, errors.New("signature is not canonical")
+	}
+	pubKeyPoint, ok := curve.Decode(pubKey)
+	if !ok {
+		return ZERO_COMMITTED_SIG, errors.New("invalid public key encoding")
+	}
+
+	r := pubKeyPoint.MulAddGenVarTime(s.S, s.E).Encode()
+	if !HashChallenge(r, hashedMsg).Equals(s.E) {
+		return ZERO_COMMITTED_SIG, errors.New("signature is invalid")
+	}
+
+	return CommittedSignature{R: r, S: s.S}, nil
+}
+
+// ToSignature converts a committed signature back into the (s, e) form, by
+// computing e = H(r || H(m)).
+func (c CommittedSignature) ToSignature(hashedMsg gFp5.Element) Signature {
+	return Signature{
+		S: c.S,
+		E: HashChallenge(c.R, hashedMsg),
+	}
+}
+
+// (r little endian) || (s little endian)
+func (c CommittedSignature) ToBytes() []byte {
+	res := make([]byte, 80)
+	copy(res[:40], c.R.ToLittleEndianBytes())
+	copy(res[40:], c.S.ToLittleEndianBytes())
+	return res
+}
+
+func CommittedSigFromBytes(b []byte) (CommittedSignature, error) {
+	if len(b) != 80 {
+		return ZERO_COMMITTED_SIG, errors.New("invalid signature length, must be 80 bytes")
+	}
+
+	r, err := gFp5.FromCanonicalLittleEndianBytes(b[:40])
+	if err != nil {
+		return ZERO_COMMITTED_SIG, fmt.Errorf("failed to convert commitment bytes to field element: %w", err)
+	}
+	sc, err := curve.ScalarFromCanonicalBytes(b[40:])
+	if err != nil {
+		return ZERO_COMMITTED_SIG, fmt.Errorf("invalid s: %w", err)
+	}
+	return CommittedSignature{R: r, S: sc}, nil
+}
+
+// IsCommittedSignatureValid verifies a Schnorr signature in committed form.
+// It accepts exactly the signatures for which ToSignature() yields a
+// signature accepted by IsSchnorrSignatureValid(), but the check
+// s*G + e*pk = r uses lattice basis reduction and is faster.
+func IsCommittedSignatureValid(pubKey, hashedMsg gFp5.Element, sig CommittedSignature) bool {
+	if !sig.S.IsCanonical() {
+		return false
+	}
+	pubKeyPoint, ok := curve.Decode(pubKey)
+	if !ok {
+		return false
+	}
+	r, ok := curve.Decode(sig.R)
+	if !ok {
+		return false
+	}
+
+	return pubKeyPoint.VerifyMulAddVarTime(sig.S, HashChallenge(sig.R, hashedMsg), r)
+}
+
+// Decoded signature, ready for (batch) verification.
+type batchEntry struct {
+	pk, r curve.ECgFp5Point
+	s, e  curve.ECgFp5Scalar
+}
+
+// BatchVerify verifies several signatures at once. Signature sigs[i] is
+// verified against public key pubKeys[i] and hashed message hashedMsgs[i];
+// the three slices must have the same length.
+//
+// All verification equations are combined with random 128-bit
+// coefficients z_i into a single multi-scalar check:
+//
+//	(\sum_i z_i*s_i)*G + \sum_i (z_i*e_i)*pk_i - \sum_i z_i*r_i = 0
+//
+// If that check fails, then the batch is split in halves, recursively, to
+// pinpoint the invalid signatures. Returned values are true and nil if all
+// signatures are valid; otherwise, false and the sorted indices of the
+// invalid signatures.
+func BatchVerify(pubKeys, hashedMsgs []gFp5.Element, sigs []CommittedSignature) (bool, []int) {
+	if len(pubKeys) != len(sigs) || len(hashedMsgs) != len(sigs) {
+		panic("BatchVerify: pubKeys, hashedMsgs and sigs must have the same length")
+	}
+
+	// Signatures with undecodable points or non-canonical scalars are
+	// invalid by themselves and left out of the combined check.
+	var invalid []int
+	entries := make([]batchEntry, len(sigs))
+	candidates := make([]int, 0, len(sigs))
+	for i, sig := range sigs {
+		pk, okPk := curve.Decode(pubKeys[i])
+		r, okR := curve.Decode(sig.R)
+		if !okPk || !okR || !sig.S.IsCanonical() {
+			invalid = append(invalid, i)
+			continue
+		}
+		entries[i] = batchEntry{pk: pk, r: r, s: sig.S, e: HashChallenge(sig.R, hashedMsgs[i])}
+		candidates = append(candidates, i)
+	}
+
+	if len(candidates) > 0 && !batchCheck(entries, candidates) {
+		invalid = append(invalid, findInvalid(entries, candidates)...)
+	}
+	if len(invalid) == 0 {
+		return true, nil
+	}
+
+	sort.Ints(invalid)
+	return false, invalid
+}
+
+// Locate the invalid entries in a set which failed the combined check.
+func findInvalid(entries []batchEntry, idx []int) []int {
+	if len(idx) == 1 {
+		return idx
+	}
+
+	var res []int
+	for _, half := range [][]int{idx[:len(idx)/2], idx[len(idx)/2:]} {
+		if !batchCheck(entries, half) {
+			res = append(res, findInvalid(entries, half)...)
+		}
+	}
+	return res
+}
+
+// Check the combined verification equation for the provided entries.
+func batchCheck(entries []batchEntry, idx []int) bool {
+	if len(idx) == 1 {
+		e := entries[idx[0]]
+		return e.pk.VerifyMulAddVarTime(e.s, e.e, e.r)
+	}
+
+	points := make([]curve.ECgFp5Point, 0, 2*len(idx)+1)
+	scalars := make([]curve.ECgFp5Scalar, 0, 2*len(idx)+1)
+	sumS := curve.ZERO
+	for _, i := range idx {
+		e := entries[i]
+		z := sampleBatchCoefficient()
+		sumS = sumS.Add(z.Mul(e.s))
+		points = append(points, e.pk, e.r)
+		scalars = append(scalars, z.Mul(e.e), curve.ZERO.Sub(z))
+	}
+	points = append(points, curve.GENERATOR_ECgFp5Point)
+	scalars = append(scalars, sumS)
+
+	return curve.MultiScalarMulVarTime(points, scalars).IsNeutral()
+}
+
+// Sample a random non-zero 128-bit scalar.
+func sampleBatchCoefficient() curve.ECgFp5Scalar {
+	var buf [16]byte
+	for {
+		if _, err := cryptorand.Read(buf[:]); err != nil {
+			panic("failed to read random bytes into buffer")
+		}
+		z := curve.ECgFp5Scalar{binary.LittleEndian.Uint64(buf[:8]), binary.LittleEndian.Uint64(buf[8:]), 0, 0, 0}
+		if !z.Equals(curve.ZERO) {
+			return z
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package signature
+
+import (
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+func makeBatch(n int) ([]gFp5.Element, []gFp5.Element, []CommittedSignature) {
+	pks := make([]gFp5.Element, n)
+	msgs := make([]gFp5.Element, n)
+	sigs := make([]CommittedSignature, n)
+	for i := 0; i < n; i++ {
+		sk := curve.SampleScalar()
+		pks[i] = SchnorrPkFromSk(sk)
+		msgs[i] = gFp5.Sample()
+		sig, err := SchnorrSignHashedMessage(msgs[i], sk).ToCommitted(pks[i], msgs[i])
+		if err != nil {
+			panic(err)
+		}
+		sigs[i] = sig
+	}
+	return pks, msgs, sigs
+}
+
+func TestCommittedSignatureConversion(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := SchnorrPkFromSk(sk)
+	hashedMsg := gFp5.Sample()
+	sig := SchnorrSignHashedMessage(hashedMsg, sk)
+
+	committed, err := sig.ToCommitted(pk, hashedMsg)
+	if err != nil {
+		t.Fatalf("ToCommitted failed: %v", err)
+	}
+	if !IsCommittedSignatureValid(pk, hashedMsg, committed) {
+		t.Fatalf("Committed signature is invalid")
+	}
+
+	back := committed.ToSignature(hashedMsg)
+	if !back.S.Equals(sig.S) || !back.E.Equals(sig.E) {
+		t.Fatalf("Round trip through committed form changed the signature")
+	}
+
+	parsed, err := CommittedSigFromBytes(committed.ToBytes())
+	if err != nil {
+		t.Fatalf("CommittedSigFromBytes failed: %v", err)
+	}
+	if !gFp5.Equals(parsed.R, committed.R) || !parsed.S.Equals(committed.S) {
+		t.Fatalf("bytes do not match")
+	}
+	malleated := CommittedSignature{R: committed.R, S: committed.S.AddInner(curve.N)}
+	if _, err := CommittedSigFromBytes(malleated.ToBytes()); err == nil {
+		t.Fatalf("Expected CommittedSigFromBytes to reject a non-canonical s")
+	}
+
+	// Invalid signatures have no committed form.
+	if _, err := sig.ToCommitted(pk, gFp5.Sample()); err == nil {
+		t.Fatalf("Expected ToCommitted to fail for a different message")
+	}
+	if _, err := sig.ToCommitted(SchnorrPkFromSk(curve.SampleScalar()), hashedMsg); err == nil {
+		t.Fatalf("Expected ToCommitted to fail for a different public key")
+	}
+
+	// Committed verification rejects exactly what the standard one rejects.
+	if IsCommittedSignatureValid(pk, gFp5.Sample(), committed) {
+		t.Fatalf("Expected committed signature to be rejected for a different message")
+	}
+	tampered := committed
+	tampered.S = tampered.S.Add(curve.ONE)
+	if IsCommittedSignatureValid(pk, hashedMsg, tampered) {
+		t.Fatalf("Expected tampered committed signature to be rejected")
+	}
+	if IsSchnorrSignatureValid(pk, hashedMsg, tampered.ToSignature(hashedMsg)) {
+		t.Fatalf("Expected converted tampered signature to be rejected")
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	pks, msgs, sigs := makeBatch(16)
+
+	if ok, invalid := BatchVerify(pks, msgs, sigs); !ok || invalid != nil {
+		t.Fatalf("Expected batch to be valid, got invalid indices %v", invalid)
+	}
+	if ok, invalid := BatchVerify(nil, nil, nil); !ok || invalid != nil {
+		t.Fatalf("Expected empty batch to be valid")
+	}
+
+	// Corrupt a few signatures in different ways.
+	sigs[3].S = sigs[3].S.Add(curve.ONE)
+	msgs[7] = gFp5.Sample()
+	sigs[11].R = sigs[12].R
+	pks[14] = gFp5.Sample() // most likely not decodable
+	sigs[15].S = sigs[15].S.AddInner(curve.N)
+
+	ok, invalid := BatchVerify(pks, msgs, sigs)
+	expected := []int{3, 7, 11, 14, 15}
+	if ok || len(invalid) != len(expected) {
+		t.Fatalf("Expected invalid indices %v, got %v", expected, invalid)
+	}
+	for i := range expected {
+		if invalid[i] != expected[i] {
+			t.Fatalf("Expected invalid indices %v, got %v", expected, invalid)
+		}
+	}
+
+	// Batch verification agrees with single verification.
+	for i := range sigs {
+		single := IsCommittedSignatureValid(pks[i], msgs[i], sigs[i])
+		if single == (i == 3 || i == 7 || i == 11 || i == 14 || i == 15) {
+			t.Fatalf("Single verification disagrees with batch verification at index %d", i)
+		}
+	}
+}
+
+func BenchmarkCommittedSignatureVerify(b *testing.B) {
+	pks, msgs, sigs := makeBatch(1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !IsCommittedSignatureValid(pks[0], msgs[0], sigs[0]) {
+			b.Fatalf("Signature is invalid")
+		}
+	}
+}
+
+func BenchmarkBatchVerify64(b *testing.B) {
+	pks, msgs, sigs := makeBatch(64)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, _ := BatchVerify(pks, msgs, sigs); !ok {
+			b.Fatalf("Batch is invalid")
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package signature
+
+import (
+	"encoding/hex"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+// Known-answer vectors for deterministic key-prefixed signatures, to be
+// matched by other implementations. None of them is a valid standard
+// signature, nor a valid signature under the other context.
+func TestContextSignatureVectors(t *testing.T) {
+	sks, hashedMessages := katInputs()
+	testCases := []struct {
+		input   int
+		context string
+		sig     string
+	}{
+		{0, "", "4f9c472378a36644343105dcfbf801b5cd9579cf7f1790f608010a7d980ac8293998f6c1a0ee453a86daec0f6d5560ba0d44ad0e0a7da0d962649b0c375faa7281029ef388ca4ba8fc0e68e6bb418946"},
+		{0, "lighter/tx/v1", "e3052a4a8cb38b5c3e5f8089e74f409c4826d4238c1a48ee6c6b45f53d1ad2990ca55a7025106c27b1299820dc16bf1cc2bddded97291b7727162b7baf8acd79d039ae0c53d81fe57e01b39c94d5cd1c"},
+		{1, "", "d422e71dd58f94f103dae6e9977719345cd08372cd6e37eeb2bbe681288eb48cc30662e8658a5478d502aeaa47d0a1cb0ad4b22adbc58822c17e42b9db9c0f894986d0848c31ca392730e876697ad13d"},
+		{1, "lighter/tx/v1", "5ae7fc8f8b65974c9278dcf5ebc6e0b74f5ef7105a56395995f595c2e2d929d98391c7e024e4d07921b29034ba28e21d83d9f9a16e59271c46a5f1fe9b28f0f10b92f344d38dd6d14608790f787f7b0a"},
+		{2, "", "e85bf1da75233eafb84932511ecd65054b13919a3a5c1cc825b7ddccc6c68575def3f71025098a5a238bbfff0e66e9a71a924fcd177278222aa20d2c62b9e49ae671ef1a0e945e817a799b3149780d1a"},
+		{2, "lighter/tx/v1", "64dbeabb0e77b6825e4e508c5c476651826bca7c1a0a0fc3bc890017e1e3c8c51bc63042145303602edb7bc072b7bc5725a8499bf2cce5b98bafa2e15c83ccf430ca15dc43cfb8aa9d6b06db62e63b00"},
+	}
+	for _, tc := range testCases {
+		sk, hashedMsg := sks[tc.input], hashedMessages[tc.input]
+		pk := SchnorrPkFromSk(sk)
+		context := []byte(tc.context)
+
+		sig := SchnorrSignHashedMessageWithContextDeterministic(hashedMsg, sk, context)
+		if got := hex.EncodeToString(sig.ToBytes()); got != tc.sig {
+			t.Fatalf("input %d, context %q: expected %s, got %s", tc.input, tc.context, tc.sig, got)
+		}
+		if !IsSchnorrSignatureValidWithContext(pk, hashedMsg, context, sig) {
+			t.Fatalf("input %d, context %q: signature is invalid", tc.input, tc.context)
+		}
+
+		if IsSchnorrSignatureValid(pk, hashedMsg, sig) {
+			t.Fatalf("input %d, context %q: verifies as a standard signature", tc.input, tc.context)
+		}
+		if IsSchnorrSignatureValidWithContext(pk, hashedMsg, []byte(tc.context+"x"), sig) {
+			t.Fatalf("input %d, context %q: verifies under another context", tc.input, tc.context)
+		}
+
+		// The other way round: standard signatures never verify as
+		// key-prefixed ones, whatever the context.
+		std := SchnorrSignHashedMessageDeterministic(hashedMsg, sk)
+		if IsSchnorrSignatureValidWithContext(pk, hashedMsg, context, std) {
+			t.Fatalf("input %d, context %q: standard signature verifies as key-prefixed", tc.input, tc.context)
+		}
+	}
+}
+
+func TestContextEmptyAndNilAreEqual(t *testing.T) {
+	sks, hashedMessages := katInputs()
+	a := SchnorrSignHashedMessageWithContextDeterministic(hashedMessages[0], sks[0], nil)
+	b := SchnorrSignHashedMessageWithContextDeterministic(hashedMessages[0], sks[0], []byte{})
+	if a != b {
+		t.Fatalf("nil and empty contexts should be the same context")
+	}
+}
+
+func TestContextBindsPublicKey(t *testing.T) {
+	sk := curve.SampleScalar()
+	hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{1, 2, 3})
+	context := []byte("test")
+	sig := SchnorrSignHashedMessageWithContext(hashedMsg, sk, context)
+
+	// Related-key forgery: (s - e*t, e) is a standard signature for
+	// pk + t*G, but not a key-prefixed one, since e depends on the key.
+	tweak := curve.SampleScalar()
+	relatedPk := curve.MulGen(sk.Add(tweak)).Encode()
+	forged := Signature{S: sig.S.Sub(sig.E.Mul(tweak)), E: sig.E}
+	if IsSchnorrSignatureValidWithContext(relatedPk, hashedMsg, context, forged) {
+		t.Fatalf("Signature should not transfer to a related key")
+	}
+
+	std := SchnorrSignHashedMessage(hashedMsg, sk)
+	forgedStd := Signature{S: std.S.Sub(std.E.Mul(tweak)), E: std.E}
+	if !IsSchnorrSignatureValid(relatedPk, hashedMsg, forgedStd) {
+		t.Fatalf("Standard signatures are expected to transfer to related keys")
+	}
+}
+
+func TestContextTypedKeys(t *testing.T) {
+	key, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{4, 5})
+	context := []byte("lighter/tx/v1")
+
+	sig := key.SignHashedMessageWithContext(hashedMsg, context)
+	if !key.PublicKey().VerifyWithContext(hashedMsg, context, sig) {
+		t.Fatalf("Key-prefixed signature is invalid")
+	}
+	if key.PublicKey().Verify(hashedMsg, sig) {
+		t.Fatalf("Key-prefixed signature should not verify as a standard signature")
+	}
+
+	pk := key.PublicKey().Bytes()
+	msg := hashedMsg.ToLittleEndianBytes()
+	if err := ValidateWithContext(pk, msg, sig.ToBytes(), context); err != nil {
+		t.Fatalf("ValidateWithContext failed: %v", err)
+	}
+	if err := ValidateWithContext(pk, msg, sig.ToBytes(), nil); err == nil {
+		t.Fatalf("ValidateWithContext should reject another context")
+	}
+	if err := Validate(pk, msg, sig.ToBytes()); err == nil {
+		t.Fatalf("Validate should reject key-prefixed signatures")
+	}
+}
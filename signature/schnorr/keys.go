@@ -0,0 +1,237 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+// Length of encoded keys, in bytes.
+const (
+	PrivateKeySize = 40
+	PublicKeySize  = 40
+)
+
+// PrivateKey is a Schnorr secret key: a non-zero scalar, along with the
+// corresponding public key. It implements crypto.Signer.
+type PrivateKey struct {
+	sk curve.ECgFp5Scalar
+	pk *PublicKey
+}
+
+// PublicKey is a Schnorr public key: a group element other than the
+// neutral, kept both encoded and decoded.
+type PublicKey struct {
+	pk    gFp5.Element
+	point curve.ECgFp5Point
+}
+
+var _ crypto.Signer = (*PrivateKey)(nil)
+
+// GenerateKey generates a new private key with randomness from rand (or
+// crypto/rand if rand is nil).
+func GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	sk, err := curve.SampleScalarFrom(rand)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(sk)
+}
+
+// NewPrivateKey wraps a secret scalar, which must be canonical and
+// non-zero.
+func NewPrivateKey(sk curve.ECgFp5Scalar) (*PrivateKey, error) {
+	if !sk.IsCanonical() {
+		return nil, errors.New("private key is not canonical")
+	}
+	if sk.Equals(curve.ZERO) {
+		return nil, errors.New("private key is zero")
+	}
+
+	point := curve.MulGen(sk)
+	return &PrivateKey{
+		sk: sk,
+		pk: &PublicKey{pk: point.Encode(), point: point},
+	}, nil
+}
+
+// PrivateKeyFromBytes decodes a private key from its 40-byte canonical
+// little-endian encoding.
+func PrivateKeyFromBytes(b []byte) (*PrivateKey, error) {
+	if len(b) != PrivateKeySize {
+		return nil, errors.New("invalid private key length, must be 40 bytes")
+	}
+	sk, err := curve.ScalarFromCanonicalBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return NewPrivateKey(sk)
+}
+
+// PrivateKeyFromHex decodes a private key from the hexadecimal string of
+// its encoding (an optional "0x" prefix is allowed).
+func PrivateKeyFromHex(s string) (*PrivateKey, error) {
+	b, err := decodeHex(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key hex: %w", err)
+	}
+	return PrivateKeyFromBytes(b)
+}
+
+// Scalar returns the secret scalar.
+func (k *PrivateKey) Scalar() curve.ECgFp5Scalar {
+	return k.sk
+}
+
+// Public returns the public key, as a *PublicKey.
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return k.pk
+}
+
+// PublicKey returns the public key.
+func (k *PrivateKey) PublicKey() *PublicKey {
+	return k.pk
+}
+
+// Equal reports whether k and x are the same private key. The comparison
+// is constant-time.
+func (k *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare(k.Bytes(), xx.Bytes()) == 1
+}
+
+// Bytes returns the 40-byte little-endian encoding of the secret scalar.
+func (k *PrivateKey) Bytes() []byte {
+	return k.sk.ToLittleEndianBytes()
+}
+
+// Hex returns the hexadecimal string of Bytes().
+func (k *PrivateKey) Hex() string {
+	return hex.EncodeToString(k.Bytes())
+}
+
+// SignHashedMessage signs the hashed message with a hedged nonce, as
+// SchnorrSignHashedMessage().
+func (k *PrivateKey) SignHashedMessage(hashedMsg gFp5.Element) Signature {
+	return SchnorrSignHashedMessage(hashedMsg, k.sk)
+}
+
+// Sign implements crypto.Signer. The digest is the 40-byte canonical
+// encoding of the hashed message (an Fp5 element), and opts.HashFunc()
+// must be zero since the message is not hashed again. The returned value
+// is the 80-byte encoding of the signature.
+//
+// If rand is not nil, then 32 bytes are read from it as extra entropy
+// for the nonce (see DeriveNonce); otherwise, the signature is
+// deterministic.
+func (k *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != crypto.Hash(0) {
+		return nil, errors.New("digest must be a hashed message (opts.HashFunc() must be zero)")
+	}
+	hashedMsg, err := gFp5.FromCanonicalLittleEndianBytes(digest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest: %w", err)
+	}
+	// Each limb must be lower than the modulus, so that distinct digests
+	// are distinct messages.
+	if !bytes.Equal(hashedMsg.ToLittleEndianBytes(), digest) {
+		return nil, errors.New("invalid digest: field element is not canonical")
+	}
+
+	if rand == nil {
+		return SchnorrSignHashedMessageDeterministic(hashedMsg, k.sk).ToBytes(), nil
+	}
+	extra, err := readHedgedEntropy(rand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return SchnorrSignHashedMessageHedged(hashedMsg, k.sk, extra).ToBytes(), nil
+}
+
+// NewPublicKey validates an encoded public key: it must decode to a group
+// element, other than the neutral.
+func NewPublicKey(pk gFp5.Element) (*PublicKey, error) {
+	point, ok := curve.Decode(pk)
+	if !ok {
+		return nil, errors.New("invalid public key encoding")
+	}
+	if point.IsNeutral() {
+		return nil, errors.New("public key is the neutral element")
+	}
+	return &PublicKey{pk: point.Encode(), point: point}, nil
+}
+
+// PublicKeyFromBytes decodes a public key from its 40-byte encoding.
+func PublicKeyFromBytes(b []byte) (*PublicKey, error) {
+	if len(b) != PublicKeySize {
+		return nil, errors.New("invalid public key length, must be 40 bytes")
+	}
+	point, err := curve.PointFromBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return NewPublicKey(point.Encode())
+}
+
+// PublicKeyFromHex decodes a public key from the hexadecimal string of
+// its encoding (an optional "0x" prefix is allowed).
+func PublicKeyFromHex(s string) (*PublicKey, error) {
+	b, err := decodeHex(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key hex: %w", err)
+	}
+	return PublicKeyFromBytes(b)
+}
+
+// Element returns the public key encoded as an Fp5 element, as used by
+// the functions of this package which take a bare public key.
+func (pk *PublicKey) Element() gFp5.Element {
+	return pk.pk
+}
+
+// Point returns the public key as a group element.
+func (pk *PublicKey) Point() curve.ECgFp5Point {
+	return pk.point
+}
+
+// Equal reports whether pk and x are the same public key.
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	if !ok {
+		return false
+	}
+	return gFp5.Equals(pk.pk, xx.pk)
+}
+
+// Bytes returns the 40-byte encoding of the public key.
+func (pk *PublicKey) Bytes() []byte {
+	return pk.pk.ToLittleEndianBytes()
+}
+
+// Hex returns the hexadecimal string of Bytes().
+func (pk *PublicKey) Hex() string {
+	return hex.EncodeToString(pk.Bytes())
+}
+
+// Verify checks a signature on the hashed message, as
+// IsSchnorrSignatureValid().
+func (pk *PublicKey) Verify(hashedMsg gFp5.Element, sig Signature) bool {
+	return IsSchnorrSignatureValid(pk.pk, hashedMsg, sig)
+}
+
+func decodeHex(s string) ([]byte, error) {
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	return hex.DecodeString(s)
+}
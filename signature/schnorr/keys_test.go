@@ -0,0 +1,144 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+func TestKeys(t *testing.T) {
+	sk, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pk := sk.PublicKey()
+	if !gFp5.Equals(pk.Element(), SchnorrPkFromSk(sk.Scalar())) || !pk.Point().Equals(curve.MulGen(sk.Scalar())) {
+		t.Fatalf("public key does not match the secret key")
+	}
+	if sk.Public().(*PublicKey) != pk {
+		t.Fatalf("Public() should return the *PublicKey")
+	}
+
+	// Encoding round trips.
+	sk2, err := PrivateKeyFromBytes(sk.Bytes())
+	if err != nil || !sk2.Equal(sk) || !sk2.PublicKey().Equal(pk) {
+		t.Fatalf("PrivateKeyFromBytes: key does not round-trip: %v", err)
+	}
+	sk3, err := PrivateKeyFromHex("0x" + strings.ToUpper(sk.Hex()))
+	if err != nil || !sk3.Equal(sk) {
+		t.Fatalf("PrivateKeyFromHex: key does not round-trip: %v", err)
+	}
+	pk2, err := PublicKeyFromBytes(pk.Bytes())
+	if err != nil || !pk2.Equal(pk) {
+		t.Fatalf("PublicKeyFromBytes: key does not round-trip: %v", err)
+	}
+	pk3, err := PublicKeyFromHex(pk.Hex())
+	if err != nil || !pk3.Equal(pk) {
+		t.Fatalf("PublicKeyFromHex: key does not round-trip: %v", err)
+	}
+
+	other, _ := GenerateKey(cryptorand.Reader)
+	if sk.Equal(other) || pk.Equal(other.PublicKey()) || sk.Equal(pk) || pk.Equal(sk) {
+		t.Fatalf("distinct keys should not be equal")
+	}
+
+	// Validation.
+	if _, err := NewPrivateKey(curve.ZERO); err == nil {
+		t.Fatalf("NewPrivateKey should reject zero")
+	}
+	if _, err := NewPrivateKey(curve.N); err == nil {
+		t.Fatalf("NewPrivateKey should reject non-canonical scalars")
+	}
+	if _, err := PrivateKeyFromBytes(curve.N.ToLittleEndianBytes()); err == nil {
+		t.Fatalf("PrivateKeyFromBytes should reject non-canonical scalars")
+	}
+	if _, err := PrivateKeyFromBytes(sk.Bytes()[:39]); err == nil {
+		t.Fatalf("PrivateKeyFromBytes should reject short inputs")
+	}
+	if _, err := PrivateKeyFromHex("zz"); err == nil {
+		t.Fatalf("PrivateKeyFromHex should reject invalid hex")
+	}
+	if _, err := NewPublicKey(gFp5.FP5_ZERO); err == nil {
+		t.Fatalf("NewPublicKey should reject the neutral")
+	}
+	if _, err := PublicKeyFromBytes(make([]byte, PublicKeySize)); err == nil {
+		t.Fatalf("PublicKeyFromBytes should reject the neutral")
+	}
+	for {
+		w := gFp5.Sample()
+		if _, ok := curve.Decode(w); ok {
+			continue
+		}
+		if _, err := NewPublicKey(w); err == nil {
+			t.Fatalf("NewPublicKey should reject invalid encodings")
+		}
+		if _, err := PublicKeyFromBytes(w.ToLittleEndianBytes()); err == nil {
+			t.Fatalf("PublicKeyFromBytes should reject invalid encodings")
+		}
+		break
+	}
+}
+
+func TestSigner(t *testing.T) {
+	sk, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	var signer crypto.Signer = sk
+	hashedMsg := gFp5.Sample()
+	digest := hashedMsg.ToLittleEndianBytes()
+
+	// Deterministic signatures with a nil reader, hedged ones otherwise.
+	sig1, err := signer.Sign(nil, digest, crypto.Hash(0))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	sig2, err := signer.Sign(nil, digest, nil)
+	if err != nil || !bytes.Equal(sig1, sig2) {
+		t.Fatalf("signatures with a nil reader should be deterministic: %v", err)
+	}
+	expected := SchnorrSignHashedMessageDeterministic(hashedMsg, sk.Scalar()).ToBytes()
+	if !bytes.Equal(sig1, expected) {
+		t.Fatalf("Sign with a nil reader should match SchnorrSignHashedMessageDeterministic")
+	}
+	sig3, err := signer.Sign(cryptorand.Reader, digest, crypto.Hash(0))
+	if err != nil || bytes.Equal(sig1, sig3) {
+		t.Fatalf("signatures with a reader should be hedged: %v", err)
+	}
+
+	for _, b := range [][]byte{sig1, sig3} {
+		sig, err := SigFromBytes(b)
+		if err != nil {
+			t.Fatalf("SigFromBytes failed: %v", err)
+		}
+		if !sk.PublicKey().Verify(hashedMsg, sig) {
+			t.Fatalf("signature is invalid")
+		}
+	}
+	if !sk.PublicKey().Verify(hashedMsg, sk.SignHashedMessage(hashedMsg)) {
+		t.Fatalf("signature is invalid")
+	}
+
+	// Invalid digests and options.
+	if _, err := signer.Sign(nil, digest, crypto.SHA256); err == nil {
+		t.Fatalf("Sign should reject a hash function")
+	}
+	if _, err := signer.Sign(nil, digest[:32], nil); err == nil {
+		t.Fatalf("Sign should reject short digests")
+	}
+	bad := make([]byte, 40)
+	binary.LittleEndian.PutUint64(bad[8:], g.ORDER)
+	if _, err := signer.Sign(nil, bad, nil); err == nil {
+		t.Fatalf("Sign should reject non-canonical digests")
+	}
+	if _, err := signer.Sign(bytes.NewReader(make([]byte, 8)), digest, nil); err == nil {
+		t.Fatalf("Sign should fail when the reader runs out of bytes")
+	}
+}
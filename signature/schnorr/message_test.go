@@ -0,0 +1,91 @@
+package signature
+
+import (
+	"testing"
+
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+func TestHashMessageVectors(t *testing.T) {
+	testCases := []struct {
+		domain, msg string
+		expected    [5]uint64
+	}{
+		{"", "", [5]uint64{8035915597539185208, 3787077645416736510, 2959572580204820315, 251302158716521920, 9003982138297349218}},
+		{"", "hello", [5]uint64{11172371576650517962, 1519640929586126141, 8506442777962903559, 673843511798475714, 8107395097783005766}},
+		{"lighter", "hello", [5]uint64{14326125579365982496, 10568277576030308869, 6941975394241131672, 14916600952684695909, 15657142897161381088}},
+		{"lighter", "a message longer than several seven-byte chunks", [5]uint64{11084747818187435483, 2411924766171801050, 9343967422161794084, 8023015669868368036, 4968241811688319387}},
+	}
+	for _, tc := range testCases {
+		h := HashMessage([]byte(tc.domain), []byte(tc.msg))
+		if h.ToUint64Array() != tc.expected {
+			t.Errorf("HashMessage(%q, %q): wrong hash", tc.domain, tc.msg)
+		}
+	}
+}
+
+func TestHashMessageEncoding(t *testing.T) {
+	// "hello" is a single chunk; the empty domain is its length only.
+	input := []g.GoldilocksField{MESSAGE_DOMAIN_TAG, 0, 5, 0x6f6c6c6568}
+	if !gFp5.Equals(HashMessage(nil, []byte("hello")), p2.HashToQuinticExtension(input)) {
+		t.Fatalf("HashMessage: wrong input encoding")
+	}
+}
+
+func TestHashMessageInjective(t *testing.T) {
+	// Pairs which would collide with a naive concatenation, or with
+	// zero-padding of the last chunk.
+	pairs := [][2]string{
+		{"", ""},
+		{"ab", "c"},
+		{"a", "bc"},
+		{"", "abc"},
+		{"abc", ""},
+		{"", "abcdefg"},
+		{"", "abcdefg\x00"},
+		{"", "\x00"},
+		{"\x00", ""},
+		{"", "\x07abcdefg"},
+	}
+	seen := make(map[[5]uint64][2]string)
+	for _, p := range pairs {
+		h := HashMessage([]byte(p[0]), []byte(p[1])).ToUint64Array()
+		if q, ok := seen[h]; ok {
+			t.Fatalf("HashMessage collision between %q and %q", p, q)
+		}
+		seen[h] = p
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	sk, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pk := sk.PublicKey()
+	domain := []byte("lighter-test")
+	msg := []byte("hello world")
+
+	sig := SignMessage(sk, domain, msg)
+	if !VerifyMessage(pk, domain, msg, sig) {
+		t.Fatalf("signature is invalid")
+	}
+	if !IsSchnorrSignatureValid(pk.Element(), HashMessage(domain, msg), sig) {
+		t.Fatalf("signature should be valid for the hashed message")
+	}
+	if VerifyMessage(pk, []byte("other-domain"), msg, sig) {
+		t.Fatalf("signature should not verify in another domain")
+	}
+	if VerifyMessage(pk, domain, []byte("hello world!"), sig) {
+		t.Fatalf("signature should not verify for another message")
+	}
+	other, _ := GenerateKey(nil)
+	if VerifyMessage(other.PublicKey(), domain, msg, sig) {
+		t.Fatalf("signature should not verify with another key")
+	}
+	if gFp5.Equals(HashMessage(nil, msg), HashMessage(domain, msg)) {
+		t.Fatalf("domains should separate hashes")
+	}
+}
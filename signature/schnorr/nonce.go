@@ -0,0 +1,102 @@
+package signature
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+// Domain separation tags for nonce derivation, as ASCII strings read as
+// little-endian 64-bit integers.
+const (
+	// "nonce-v1": nonces of standard signatures.
+	NONCE_DOMAIN_TAG = g.GoldilocksField(0x31762d65636e6f6e)
+	// "nonce-s1": nonces of short signatures (see short.go). A nonce must
+	// never be used with two different challenges for the same message,
+	// which would reveal the secret key.
+	SHORT_NONCE_DOMAIN_TAG = g.GoldilocksField(0x31732d65636e6f6e)
+	// "nonce-c1": nonces of key-prefixed signatures (see context.go),
+	// followed by the context.
+	CONTEXT_NONCE_DOMAIN_TAG = g.GoldilocksField(0x31632d65636e6f6e)
+)
+
+// Number of 32-bit extra entropy elements used by hedged signing.
+const HEDGED_ENTROPY_ELEMENTS = 8
+
+// DeriveNonce computes the signing nonce k from the secret key, the hashed
+// message and optional extra entropy, in the spirit of RFC 6979. The same
+// inputs always yield the same nonce, so that a broken RNG cannot leak the
+// secret key through nonce reuse.
+//
+// The derivation is, with all field elements in canonical form:
+//
+//	input = NONCE_DOMAIN_TAG                      (1 element)
+//	     || sk.SplitTo32BitLimbs()                (10 elements)
+//	     || hashedMsg                             (5 elements)
+//	     || len(extra)                            (1 element)
+//	     || extra                                 (len(extra) elements)
+//	k = HashToScalar(input)
+//
+// i.e. ten elements h_i are squeezed from the Poseidon2 sponge, and
+// k = (\sum_i h_i*2^(64*i)) mod n.
+//
+// Extra entropy turns this into a hedged derivation; callers which pass
+// nil obtain fully deterministic signatures. The nonce is a secret value.
+func DeriveNonce(hashedMsg gFp5.Element, sk curve.ECgFp5Scalar, extra []g.GoldilocksField) curve.ECgFp5Scalar {
+	return deriveNonce([]g.GoldilocksField{NONCE_DOMAIN_TAG}, hashedMsg, sk, extra)
+}
+
+// Derive a nonce as DeriveNonce, with the domain (a tag, possibly followed
+// by self-delimiting parameters) in place of NONCE_DOMAIN_TAG.
+func deriveNonce(domain []g.GoldilocksField, hashedMsg gFp5.Element, sk curve.ECgFp5Scalar, extra []g.GoldilocksField) curve.ECgFp5Scalar {
+	skLimbs := sk.SplitTo32BitLimbs()
+
+	input := make([]g.GoldilocksField, 0, len(domain)+10+5+1+len(extra))
+	input = append(input, domain...)
+	input = append(input, skLimbs[:]...)
+	input = append(input, hashedMsg[:]...)
+	input = append(input, g.GoldilocksField(uint64(len(extra))))
+	input = append(input, extra...)
+
+	return curve.HashToScalar(input)
+}
+
+// SchnorrSignHashedMessageDeterministic signs with the nonce
+// DeriveNonce(hashedMsg, sk, nil); the signature only depends on the
+// message and the secret key.
+func SchnorrSignHashedMessageDeterministic(hashedMsg gFp5.Element, sk curve.ECgFp5Scalar) Signature {
+	return SchnorrSignHashedMessage2(hashedMsg, sk, DeriveNonce(hashedMsg, sk, nil))
+}
+
+// SchnorrSignHashedMessageHedged signs with the nonce
+// DeriveNonce(hashedMsg, sk, extra), with caller-provided extra entropy.
+func SchnorrSignHashedMessageHedged(hashedMsg gFp5.Element, sk curve.ECgFp5Scalar, extra []g.GoldilocksField) Signature {
+	return SchnorrSignHashedMessage2(hashedMsg, sk, DeriveNonce(hashedMsg, sk, extra))
+}
+
+// Sample HEDGED_ENTROPY_ELEMENTS random 32-bit field elements.
+func sampleHedgedEntropy() []g.GoldilocksField {
+	extra, err := readHedgedEntropy(cryptorand.Reader)
+	if err != nil {
+		panic("failed to read random bytes into buffer")
+	}
+	return extra
+}
+
+// Read HEDGED_ENTROPY_ELEMENTS 32-bit field elements from r.
+func readHedgedEntropy(r io.Reader) ([]g.GoldilocksField, error) {
+	var buf [4 * HEDGED_ENTROPY_ELEMENTS]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+
+	extra := make([]g.GoldilocksField, HEDGED_ENTROPY_ELEMENTS)
+	for i := range extra {
+		extra[i] = g.GoldilocksField(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return extra, nil
+}
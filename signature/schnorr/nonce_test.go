@@ -0,0 +1,119 @@
+package signature
+
+import (
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+func katInputs() ([]curve.ECgFp5Scalar, []gFp5.Element) {
+	sks := []curve.ECgFp5Scalar{
+		{12235002942052073545, 1175977464658719998, 8536934969147463310, 6524687619313720391, 2922072024880609112},
+		{14609471659974493146, 15558617123161593410, 853367204868339037, 17594253198278631904, 368396584122947478},
+		{846395111423676945, 1354180063821346280, 5751371120309175011, 4898038106472090654, 1076345918732914302},
+	}
+	hashedMessages := []gFp5.Element{
+		{8398652514106806347, 11069112711939986896, 9732488227085561369, 18076754337204438535, 17155407358725346236},
+		{14569490467507212064, 2707063505563578676, 7506743487465742335, 12569771346154554175, 4305083698940175790},
+		{17529153479246803593, 1743712677205511695, 4834285972617397460, 5486672566342530358, 7254989001695704129},
+	}
+	return sks, hashedMessages
+}
+
+// Known-answer vectors for DeriveNonce, to be matched by other
+// implementations.
+func TestDeriveNonceKnownAnswers(t *testing.T) {
+	sks, hashedMessages := katInputs()
+	extras := [][]g.GoldilocksField{
+		nil,
+		{1, 2, 3, 4, 5, 6, 7, 8},
+		{0xFFFFFFFF},
+	}
+	expectedKs := []curve.ECgFp5Scalar{
+		{5815723517500924582, 3764371574874398909, 6324954186900255223, 5196798853205640001, 6390089000975748672},
+		{14080142036942983505, 1154597168970094596, 5740639279849707536, 12848028818516780222, 1513683582028495247},
+		{9085267775980915084, 15751369626855872375, 7999074942437490236, 17451224708737610484, 1591140810098396154},
+	}
+	expectedSs := []curve.ECgFp5Scalar{
+		{5082068612936117902, 1375430468527599062, 17952569822657513197, 1926312745520998097, 930506352857315650},
+		{14772908330702944909, 9699494007869643443, 6451407809815544838, 15775231004979515905, 1044816552978912514},
+		{10189838800239712428, 5427342640431216607, 7404005316346731586, 3529414442445884190, 1367811746856529576},
+	}
+	expectedEs := []curve.ECgFp5Scalar{
+		{15697669646763976497, 8154827810664788700, 17677677425166594092, 1605105477548351061, 8625498786784845200},
+		{10499997572722852991, 1720239482148282613, 8678769304443452854, 16978814040726037616, 320420968669026647},
+		{6449439640320099834, 8916506886372191800, 8217925892541798832, 4655231121877732731, 2984298447912171511},
+	}
+
+	for i := range sks {
+		k := DeriveNonce(hashedMessages[i], sks[i], extras[i])
+		if !k.Equals(expectedKs[i]) {
+			t.Fatalf("vector %d: expected k = %v, got %v", i, expectedKs[i], k)
+		}
+
+		sig := SchnorrSignHashedMessageHedged(hashedMessages[i], sks[i], extras[i])
+		if !sig.S.Equals(expectedSs[i]) || !sig.E.Equals(expectedEs[i]) {
+			t.Fatalf("vector %d: expected (s, e) = (%v, %v), got (%v, %v)", i, expectedSs[i], expectedEs[i], sig.S, sig.E)
+		}
+		if !IsSchnorrSignatureValid(SchnorrPkFromSk(sks[i]), hashedMessages[i], sig) {
+			t.Fatalf("vector %d: signature is invalid", i)
+		}
+	}
+
+	// No extra entropy is the deterministic mode.
+	sig := SchnorrSignHashedMessageDeterministic(hashedMessages[0], sks[0])
+	if !sig.S.Equals(expectedSs[0]) || !sig.E.Equals(expectedEs[0]) {
+		t.Fatalf("deterministic signature does not match vector 0")
+	}
+}
+
+func TestDeriveNonceSeparation(t *testing.T) {
+	sk := curve.SampleScalar()
+	hashedMsg := gFp5.Sample()
+
+	k := DeriveNonce(hashedMsg, sk, nil)
+	if !k.Equals(DeriveNonce(hashedMsg, sk, nil)) {
+		t.Fatalf("DeriveNonce is not deterministic")
+	}
+
+	others := []curve.ECgFp5Scalar{
+		DeriveNonce(gFp5.Sample(), sk, nil),
+		DeriveNonce(hashedMsg, curve.SampleScalar(), nil),
+		DeriveNonce(hashedMsg, sk, []g.GoldilocksField{0}),
+		DeriveNonce(hashedMsg, sk, []g.GoldilocksField{0, 0}),
+	}
+	for i, other := range others {
+		if k.Equals(other) {
+			t.Fatalf("case %d: nonce collision", i)
+		}
+	}
+	if others[2].Equals(others[3]) {
+		t.Fatalf("extra entropy encoding is not length-injective")
+	}
+}
+
+func TestHedgedSigning(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := SchnorrPkFromSk(sk)
+	hashedMsg := gFp5.Sample()
+
+	sig1 := SchnorrSignHashedMessage(hashedMsg, sk)
+	sig2 := SchnorrSignHashedMessage(hashedMsg, sk)
+	if sig1.E.Equals(sig2.E) {
+		t.Fatalf("Expected hedged signatures of the same message to differ")
+	}
+	if !IsSchnorrSignatureValid(pk, hashedMsg, sig1) || !IsSchnorrSignatureValid(pk, hashedMsg, sig2) {
+		t.Fatalf("Signature is invalid")
+	}
+
+	det1 := SchnorrSignHashedMessageDeterministic(hashedMsg, sk)
+	det2 := SchnorrSignHashedMessageDeterministic(hashedMsg, sk)
+	if !det1.S.Equals(det2.S) || !det1.E.Equals(det2.E) {
+		t.Fatalf("Expected deterministic signatures to be identical")
+	}
+	if !IsSchnorrSignatureValid(pk, hashedMsg, det1) {
+		t.Fatalf("Signature is invalid")
+	}
+}
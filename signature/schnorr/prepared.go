@@ -0,0 +1,62 @@
+package signature
+
+import (
+	"errors"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+// PreparedPublicKey is a decoded public key along with a precomputed
+// fixed-base comb, for signers whose signatures are verified many times.
+//
+// IsSchnorrSignatureValid() decodes the public key and builds a 16-point
+// window for it on each call, and computes s*G + e*pk with about 320
+// doublings. A prepared key pays for decoding and for the comb (8 windows,
+// about 10 kB) once; each verification then only needs 35 doublings.
+// Building a prepared key costs about as much as a single verification,
+// so it pays off from the second signature onward.
+//
+// A PreparedPublicKey is immutable once built, and can be used
+// concurrently from several goroutines.
+type PreparedPublicKey struct {
+	pk    gFp5.Element
+	point curve.ECgFp5Point
+	table *curve.FixedBaseTable
+}
+
+// NewPreparedPublicKey decodes the public key and precomputes its comb. An
+// error is returned if the public key is not a valid point encoding.
+func NewPreparedPublicKey(pubKey gFp5.Element) (*PreparedPublicKey, error) {
+	point, ok := curve.Decode(pubKey)
+	if !ok {
+		return nil, errors.New("invalid public key encoding")
+	}
+
+	return &PreparedPublicKey{
+		pk:    pubKey,
+		point: point,
+		table: curve.NewFixedBaseTable(point),
+	}, nil
+}
+
+// PublicKey returns the encoded public key.
+func (p *PreparedPublicKey) PublicKey() gFp5.Element {
+	return p.pk
+}
+
+// Point returns the decoded public key.
+func (p *PreparedPublicKey) Point() curve.ECgFp5Point {
+	return p.point
+}
+
+// Verify checks a signature on the hashed message. It accepts exactly the
+// same signatures as IsSchnorrSignatureValid() with this public key.
+func (p *PreparedPublicKey) Verify(hashedMsg gFp5.Element, sig Signature) bool {
+	if !sig.IsCanonical() {
+		return false
+	}
+
+	rV := p.table.MulAddGenVarTime(sig.S, sig.E).Encode() // r_v = s*G + e*pk
+	return HashChallenge(rV, hashedMsg).Equals(sig.E)
+}
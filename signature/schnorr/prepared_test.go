@@ -0,0 +1,121 @@
+package signature
+
+import (
+	"sync"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+func TestPreparedPublicKey(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := SchnorrPkFromSk(sk)
+	prepared, err := NewPreparedPublicKey(pk)
+	if err != nil {
+		t.Fatalf("failed to prepare public key: %v", err)
+	}
+	if !gFp5.Equals(prepared.PublicKey(), pk) || !gFp5.Equals(prepared.Point().Encode(), pk) {
+		t.Fatalf("prepared public key does not match")
+	}
+
+	for i := 0; i < 10; i++ {
+		hashedMsg := gFp5.Sample()
+		sig := SchnorrSignHashedMessage(hashedMsg, sk)
+		cases := []struct {
+			msg gFp5.Element
+			sig Signature
+		}{
+			{hashedMsg, sig},
+			{gFp5.Sample(), sig},
+			{hashedMsg, Signature{S: sig.S.Add(curve.ONE), E: sig.E}},
+			{hashedMsg, Signature{S: sig.S, E: sig.E.Add(curve.ONE)}},
+			{hashedMsg, Signature{S: sig.S.AddInner(curve.N), E: sig.E}},
+			{hashedMsg, ZERO_SIG},
+		}
+		for j, c := range cases {
+			expected := IsSchnorrSignatureValid(pk, c.msg, c.sig)
+			if prepared.Verify(c.msg, c.sig) != expected {
+				t.Fatalf("case %d: prepared key disagrees with IsSchnorrSignatureValid (expected %v)", j, expected)
+			}
+			if j == 0 && !expected {
+				t.Fatalf("case %d: expected a valid signature", j)
+			}
+		}
+	}
+
+	// The neutral is a valid (if useless) public key.
+	if _, err := NewPreparedPublicKey(gFp5.FP5_ZERO); err != nil {
+		t.Fatalf("failed to prepare the neutral: %v", err)
+	}
+	for {
+		w := gFp5.Sample()
+		if _, ok := curve.Decode(w); ok {
+			continue
+		}
+		if _, err := NewPreparedPublicKey(w); err == nil {
+			t.Fatalf("NewPreparedPublicKey should reject invalid encodings")
+		}
+		break
+	}
+}
+
+func TestPreparedPublicKeyConcurrent(t *testing.T) {
+	sk := curve.SampleScalar()
+	prepared, err := NewPreparedPublicKey(SchnorrPkFromSk(sk))
+	if err != nil {
+		t.Fatalf("failed to prepare public key: %v", err)
+	}
+	msgs := make([]gFp5.Element, 8)
+	sigs := make([]Signature, len(msgs))
+	for i := range msgs {
+		msgs[i] = gFp5.Sample()
+		sigs[i] = SchnorrSignHashedMessage(msgs[i], sk)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan int, len(msgs))
+	for i := range msgs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if !prepared.Verify(msgs[i], sigs[i]) || prepared.Verify(msgs[(i+1)%len(msgs)], sigs[i]) {
+				errs <- i
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for i := range errs {
+		t.Errorf("wrong verification result for signature %d", i)
+	}
+}
+
+// Compare with BenchmarkSignatureVerify.
+func BenchmarkPreparedPublicKeyVerify(b *testing.B) {
+	sk := curve.SampleScalar()
+	hashedMsg := gFp5.Sample()
+	sig := SchnorrSignHashedMessage(hashedMsg, sk)
+	prepared, err := NewPreparedPublicKey(SchnorrPkFromSk(sk))
+	if err != nil {
+		b.Fatalf("failed to prepare public key: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !prepared.Verify(hashedMsg, sig) {
+			b.Fatalf("Signature is invalid")
+		}
+	}
+}
+
+func BenchmarkNewPreparedPublicKey(b *testing.B) {
+	pk := SchnorrPkFromSk(curve.SampleScalar())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewPreparedPublicKey(pk); err != nil {
+			b.Fatalf("failed to prepare public key: %v", err)
+		}
+	}
+}
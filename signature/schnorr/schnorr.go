@@ -14,6 +14,13 @@
 // - Poseidon2 hash function for challenge generation
 // - Pre-hashed messages (caller must hash messages to Fp5 elements)
 // - Standard Schnorr signature equation: s = k - e·sk, where e = H(r || H(m))
+// - Deterministic or hedged nonces k, derived from sk and H(m) (see DeriveNonce)
+//
+// A separate short scheme uses a 128-bit challenge, for 56-byte signatures
+// and faster verification (see short.go). Versioned encodings, prefixed
+// with a SignatureVersion byte, tell both schemes apart (see versioned.go).
+// Key-prefixed signatures bind the public key and an application context
+// into the challenge, e = H(domain || pk || r || H(m)) (see context.go).
 //
 // USAGE:
 //
@@ -30,6 +37,11 @@
 //	// Verify
 //	valid := IsSchnorrSignatureValid(pk, hashedMsg, sig)
 //
+//	// Same with typed keys (PrivateKey also implements crypto.Signer)
+//	key, err := GenerateKey(nil)
+//	sig := key.SignHashedMessage(hashedMsg)
+//	valid := key.PublicKey().Verify(hashedMsg, sig)
+//
 // SECURITY CONSIDERATIONS:
 //
 // The lack of cofactor eliminates an entire class of attacks that affect
@@ -80,42 +92,35 @@ func SigFromBytes(b []byte) (Signature, error) {
 		return ZERO_SIG, errors.New("invalid signature length, must be 80 bytes")
 	}
 
-	// ScalarElementFromLittleEndianBytes will check s and e are both in
-	// canonical form
-	return Signature{
-		S: curve.ScalarElementFromLittleEndianBytes(b[:40]),
-		E: curve.ScalarElementFromLittleEndianBytes(b[40:]),
-	}, nil
+	// Both s and e must be in canonical form; otherwise, a signature
+	// could have several valid encodings.
+	sc, err := curve.ScalarFromCanonicalBytes(b[:40])
+	if err != nil {
+		return ZERO_SIG, fmt.Errorf("invalid s: %w", err)
+	}
+	e, err := curve.ScalarFromCanonicalBytes(b[40:])
+	if err != nil {
+		return ZERO_SIG, fmt.Errorf("invalid e: %w", err)
+	}
+	return Signature{S: sc, E: e}, nil
 }
 
 // Public key is actually an EC point (4 Fp5 elements), but it can be encoded as a single Fp5 element.
 func SchnorrPkFromSk(sk curve.ECgFp5Scalar) gFp5.Element {
-	return curve.GENERATOR_ECgFp5Point.Mul(sk).Encode()
+	return curve.MulGen(sk).Encode()
 }
 
 func SchnorrSignHashedMessage(hashedMsg gFp5.Element, sk curve.ECgFp5Scalar) Signature {
-	// Sample random scalar `k` and compute `r = k * G`
-	k := curve.SampleScalar()
-	r := curve.GENERATOR_ECgFp5Point.Mul(k).Encode()
+	// Derive `k` from the secret key, the message and fresh randomness
+	// (hedged signing, see DeriveNonce), and compute `r = k * G`
+	k := DeriveNonce(hashedMsg, sk, sampleHedgedEntropy())
+	r := curve.MulGen(k).Encode()
 
 	// Compute `e = H(r || H(m))`, which is a scalar point
 	preImage := make([]g.GoldilocksField, 5+5)
 	copy(preImage[:5], r[:])
 	copy(preImage[5:], hashedMsg[:])
 
-	// TODO: Something to be considered later (and require coordinate with Rust)
-	//
-	// It is possible that we only use 128 bits for e (instread of 320 bits)
-	// That is, we can build e with the first 3 limbs of p2.HashToQuinticExtension(preImage)
-	// This should improve the performance of schnorr signature.
-	//
-	// see
-	//
-	// - Hash Function Requirements for Schnorr Signatures
-	//   Gregory Neven, Nigel P. Smart, and Bogdan Warinschi
-	// - Short Schnorr Signatures Require a Hash Function with More Than Just Random-Prefix Resistance
-	// 	 Daniel R. L. Brown
-
 	e := curve.FromGfp5(p2.HashToQuinticExtension(preImage))
 	return Signature{
 		S: k.Sub(e.Mul(sk)),
@@ -124,25 +129,12 @@ func SchnorrSignHashedMessage(hashedMsg gFp5.Element, sk curve.ECgFp5Scalar) Sig
 }
 
 func SchnorrSignHashedMessage2(hashedMsg gFp5.Element, sk, k curve.ECgFp5Scalar) Signature {
-	r := curve.GENERATOR_ECgFp5Point.Mul(k).Encode()
+	r := curve.MulGen(k).Encode()
 	// Compute `e = H(r || H(m))`, which is a scalar point
 	preImage := make([]g.GoldilocksField, 5+5)
 	copy(preImage[:5], r[:])
 	copy(preImage[5:], hashedMsg[:])
 
-	// TODO: Something to be considered later (and require coordinate with Rust)
-	//
-	// It is possible that we only use 128 bits for e (instread of 320 bits)
-	// That is, we can build e with the first 3 limbs of p2.HashToQuinticExtension(preImage)
-	// This should improve the performance of schnorr signature.
-	//
-	// see
-	//
-	// - Hash Function Requirements for Schnorr Signatures
-	//   Gregory Neven, Nigel P. Smart, and Bogdan Warinschi
-	// - Short Schnorr Signatures Require a Hash Function with More Than Just Random-Prefix Resistance
-	// 	 Daniel R. L. Brown
-
 	e := curve.FromGfp5(p2.HashToQuinticExtension(preImage))
 	return Signature{
 		S: k.Sub(e.Mul(sk)),
@@ -183,7 +175,14 @@ func Validate(pubKey, hashedMsg, sig []byte) error {
 // 2. Public key decodes successfully (canonical encoding)
 // 3. Verification equation: s·G + e·pk = r, where e = H(r || H(m))
 //
-// Returns true if signature is valid, false otherwise.
+// The check recomputes r = s·G + e·pk with a full-length MulAddGenVarTime():
+// the lattice-reduced check of ECgFp5Point.VerifyMulAddVarTime() halves e
+// but needs r as a point, which the (s, e) format does not carry. Signatures
+// in committed form (r, s) are verified with that faster path by
+// IsCommittedSignatureValid().
+//
+// Returns true if signature is valid, false otherwise. To verify many
+// signatures from the same public key, use a PreparedPublicKey instead.
 func IsSchnorrSignatureValid(pubKey, hashedMsg gFp5.Element, sig Signature) bool {
 	// Check signature canonicality (prevents malleability)
 	if !sig.IsCanonical() {
@@ -192,17 +191,24 @@ func IsSchnorrSignatureValid(pubKey, hashedMsg gFp5.Element, sig Signature) bool
 
 	// Decode public key (canonical decoding automatically ensures valid group element)
 	// No subgroup check needed due to prime order!
-	pubKeyWs, ok := curve.DecodeFp5AsWeierstrass(pubKey)
+	pubKeyPoint, ok := curve.Decode(pubKey)
 	if !ok {
 		return false
 	}
 
-	rV := curve.MulAdd2(curve.GENERATOR_WEIERSTRASS, pubKeyWs, sig.S, sig.E).Encode() // r_v = s*G + e*pk
+	rV := pubKeyPoint.MulAddGenVarTime(sig.S, sig.E).Encode() // r_v = s*G + e*pk
+	eV := HashChallenge(rV, hashedMsg)
+
+	return eV.Equals(sig.E) // e_v == e
+}
 
+// HashChallenge computes the challenge e = H(r || H(m)), with r the
+// encoded commitment point. Multi-party protocols which produce standard
+// signatures (e.g. MuSig2) use it to derive the same challenge as the
+// verifier.
+func HashChallenge(r, hashedMsg gFp5.Element) curve.ECgFp5Scalar {
 	preImage := make([]g.GoldilocksField, 5+5)
-	copy(preImage[:5], rV[:])
+	copy(preImage[:5], r[:])
 	copy(preImage[5:], hashedMsg[:])
-	eV := curve.FromGfp5(p2.HashToQuinticExtension(preImage))
-
-	return eV.Equals(sig.E) // e_v == e
+	return curve.FromGfp5(p2.HashToQuinticExtension(preImage))
 }
@@ -167,6 +167,55 @@ func TestComparativeSchnorrSignAndVerify(t *testing.T) {
 	}
 }
 
+// isSchnorrSignatureValidWeierstrass is the former verification path, kept
+// as a reference: it computes s*G + e*pk with MulAdd2 on Weierstrass points.
+func isSchnorrSignatureValidWeierstrass(pubKey, hashedMsg gFp5.Element, sig Signature) bool {
+	if !sig.IsCanonical() {
+		return false
+	}
+	pubKeyWs, ok := curve.DecodeFp5AsWeierstrass(pubKey)
+	if !ok {
+		return false
+	}
+	rV := curve.MulAdd2(curve.GENERATOR_WEIERSTRASS, pubKeyWs, sig.S, sig.E).Encode()
+
+	preImage := make([]g.GoldilocksField, 5+5)
+	copy(preImage[:5], rV[:])
+	copy(preImage[5:], hashedMsg[:])
+	return curve.FromGfp5(p2.HashToQuinticExtension(preImage)).Equals(sig.E)
+}
+
+func TestVerifyMatchesWeierstrassPath(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		sk := curve.SampleScalar()
+		pk := SchnorrPkFromSk(sk)
+		hashedMsg := gFp5.Sample()
+		sig := SchnorrSignHashedMessage(hashedMsg, sk)
+
+		cases := []struct {
+			pk, msg gFp5.Element
+			sig     Signature
+		}{
+			{pk, hashedMsg, sig},
+			{pk, gFp5.Sample(), sig},
+			{SchnorrPkFromSk(curve.SampleScalar()), hashedMsg, sig},
+			{pk, hashedMsg, Signature{S: sig.S.Add(curve.ONE), E: sig.E}},
+			{pk, hashedMsg, Signature{S: sig.S, E: sig.E.Add(curve.ONE)}},
+			{gFp5.Sample(), hashedMsg, sig},
+			{gFp5.FP5_ZERO, hashedMsg, sig},
+		}
+		for j, c := range cases {
+			expected := isSchnorrSignatureValidWeierstrass(c.pk, c.msg, c.sig)
+			if IsSchnorrSignatureValid(c.pk, c.msg, c.sig) != expected {
+				t.Fatalf("case %d: verifier disagrees with Weierstrass path (expected %v)", j, expected)
+			}
+			if j == 0 && !expected {
+				t.Fatalf("case %d: expected a valid signature", j)
+			}
+		}
+	}
+}
+
 func TestBytes(t *testing.T) {
 	sk := curve.SampleScalar() // Sample a secret key
 	msg := make([]g.GoldilocksField, 244)
@@ -193,12 +242,21 @@ func TestBytes(t *testing.T) {
 		t.Fatalf("Signature is invalid")
 	}
 
-	// Works with non-canonical inputs
-	sig3 := sig2
-	sig3.S = sig3.S.AddInner(curve.N)
-	sig3.E = sig3.E.AddInner(curve.N)
-	if err := Validate(pk.ToLittleEndianBytes(), hashedMsg.ToLittleEndianBytes(), sig3.ToBytes()); err != nil {
-		t.Fatalf("Signature is invalid")
+	// Rejects non-canonical inputs (s + n or e + n would otherwise be
+	// alternate encodings of the same signature)
+	for _, sig3 := range []Signature{
+		{S: sig2.S.AddInner(curve.N), E: sig2.E},
+		{S: sig2.S, E: sig2.E.AddInner(curve.N)},
+	} {
+		if _, err := SigFromBytes(sig3.ToBytes()); err == nil {
+			t.Fatalf("SigFromBytes should reject non-canonical scalars")
+		}
+		if err := Validate(pk.ToLittleEndianBytes(), hashedMsg.ToLittleEndianBytes(), sig3.ToBytes()); err == nil {
+			t.Fatalf("Validate should reject non-canonical scalars")
+		}
+	}
+	if _, err := SigFromBytes(sig.ToBytes()[:79]); err == nil {
+		t.Fatalf("SigFromBytes should reject short inputs")
 	}
 }
 
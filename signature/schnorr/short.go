@@ -0,0 +1,158 @@
+package signature
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+// Short signatures use a 128-bit challenge, built from the first two
+// limbs of the Poseidon2 output:
+//
+//	h = HashToQuinticExtension(SHORT_CHALLENGE_TAG || r || H(m))
+//	e = h[0] + h[1]*2^64
+//	s = k - e·sk
+//
+// Schnorr signatures only need a challenge as long as the security level
+// (see "Hash Function Requirements for Schnorr Signatures", Neven, Smart
+// and Warinschi), which makes signatures shorter (56 bytes instead of 80)
+// and verification faster, since e·pk needs about 130 doublings instead
+// of 320.
+//
+// This is a separate scheme: the challenge is domain-separated from the
+// standard one, nonces are derived with SHORT_NONCE_DOMAIN_TAG, and
+// neither scheme accepts signatures of the other.
+
+// Domain separation tag for short challenges: the ASCII string "schnr128",
+// read as a little-endian 64-bit integer.
+const SHORT_CHALLENGE_TAG = g.GoldilocksField(0x383231726e686373)
+
+// Length of an encoded short signature, in bytes.
+const ShortSignatureSize = 40 + 16
+
+type ShortSignature struct {
+	S curve.ECgFp5Scalar
+	// The 128-bit challenge; the upper three limbs are zero.
+	E curve.ECgFp5Scalar
+}
+
+var ZERO_SHORT_SIG = ShortSignature{
+	S: curve.ZERO,
+	E: curve.ZERO,
+}
+
+// IsCanonical returns true if s is canonical and e fits in 128 bits.
+func (s ShortSignature) IsCanonical() bool {
+	return s.S.IsCanonical() && s.E[2] == 0 && s.E[3] == 0 && s.E[4] == 0
+}
+
+// (s little endian, 40 bytes) || (e little endian, 16 bytes)
+func (s ShortSignature) ToBytes() []byte {
+	res := make([]byte, 0, ShortSignatureSize)
+	res = append(res, s.S.ToLittleEndianBytes()...)
+	res = binary.LittleEndian.AppendUint64(res, s.E[0])
+	return binary.LittleEndian.AppendUint64(res, s.E[1])
+}
+
+func ShortSigFromBytes(b []byte) (ShortSignature, error) {
+	if len(b) != ShortSignatureSize {
+		return ZERO_SHORT_SIG, fmt.Errorf("invalid short signature length, must be %d bytes", ShortSignatureSize)
+	}
+
+	sc, err := curve.ScalarFromCanonicalBytes(b[:40])
+	if err != nil {
+		return ZERO_SHORT_SIG, fmt.Errorf("invalid s: %w", err)
+	}
+	e := curve.ECgFp5Scalar{
+		binary.LittleEndian.Uint64(b[40:48]),
+		binary.LittleEndian.Uint64(b[48:56]),
+		0, 0, 0,
+	}
+	return ShortSignature{S: sc, E: e}, nil
+}
+
+// HashShortChallenge computes the 128-bit challenge of short signatures,
+// with r the encoded commitment point.
+func HashShortChallenge(r, hashedMsg gFp5.Element) curve.ECgFp5Scalar {
+	preImage := make([]g.GoldilocksField, 0, 1+5+5)
+	preImage = append(preImage, SHORT_CHALLENGE_TAG)
+	preImage = append(preImage, r[:]...)
+	preImage = append(preImage, hashedMsg[:]...)
+	h := p2.HashToQuinticExtension(preImage)
+	return curve.ECgFp5Scalar{h[0].ToCanonicalUint64(), h[1].ToCanonicalUint64(), 0, 0, 0}
+}
+
+// SchnorrSignHashedMessageShort signs the hashed message with a short
+// challenge and a hedged nonce.
+func SchnorrSignHashedMessageShort(hashedMsg gFp5.Element, sk curve.ECgFp5Scalar) ShortSignature {
+	return schnorrSignShort(hashedMsg, sk, deriveNonce([]g.GoldilocksField{SHORT_NONCE_DOMAIN_TAG}, hashedMsg, sk, sampleHedgedEntropy()))
+}
+
+// SchnorrSignHashedMessageShortDeterministic signs the hashed message with
+// a short challenge; the signature only depends on the message and the
+// secret key.
+func SchnorrSignHashedMessageShortDeterministic(hashedMsg gFp5.Element, sk curve.ECgFp5Scalar) ShortSignature {
+	return schnorrSignShort(hashedMsg, sk, deriveNonce([]g.GoldilocksField{SHORT_NONCE_DOMAIN_TAG}, hashedMsg, sk, nil))
+}
+
+func schnorrSignShort(hashedMsg gFp5.Element, sk, k curve.ECgFp5Scalar) ShortSignature {
+	r := curve.MulGen(k).Encode()
+	e := HashShortChallenge(r, hashedMsg)
+	return ShortSignature{
+		S: k.Sub(e.Mul(sk)),
+		E: e,
+	}
+}
+
+// IsShortSchnorrSignatureValid verifies a short signature: s·G + e·pk = r,
+// where e is the short challenge of r and H(m).
+func IsShortSchnorrSignatureValid(pubKey, hashedMsg gFp5.Element, sig ShortSignature) bool {
+	if !sig.IsCanonical() {
+		return false
+	}
+	pubKeyPoint, ok := curve.Decode(pubKey)
+	if !ok {
+		return false
+	}
+
+	rV := pubKeyPoint.MulAddGenVarTime(sig.S, sig.E).Encode()
+	return HashShortChallenge(rV, hashedMsg).Equals(sig.E)
+}
+
+// ValidateShort is Validate() for short signatures.
+func ValidateShort(pubKey, hashedMsg, sig []byte) error {
+	pk, err := gFp5.FromCanonicalLittleEndianBytes(pubKey)
+	if err != nil {
+		return fmt.Errorf("failed to convert public key bytes to field element: %w", err)
+	}
+	hashedMsgElem, err := gFp5.FromCanonicalLittleEndianBytes(hashedMsg)
+	if err != nil {
+		return fmt.Errorf("failed to convert hashed message bytes to field element: %w", err)
+	}
+	s, err := ShortSigFromBytes(sig)
+	if err != nil {
+		return fmt.Errorf("failed to convert signature bytes to short Schnorr signature: %w", err)
+	}
+
+	if !IsShortSchnorrSignatureValid(pk, hashedMsgElem, s) {
+		return errors.New("signature is invalid")
+	}
+	return nil
+}
+
+// SignHashedMessageShort signs the hashed message with a short challenge
+// and a hedged nonce, as SchnorrSignHashedMessageShort().
+func (k *PrivateKey) SignHashedMessageShort(hashedMsg gFp5.Element) ShortSignature {
+	return SchnorrSignHashedMessageShort(hashedMsg, k.sk)
+}
+
+// VerifyShort checks a short signature on the hashed message, as
+// IsShortSchnorrSignatureValid().
+func (pk *PublicKey) VerifyShort(hashedMsg gFp5.Element, sig ShortSignature) bool {
+	return IsShortSchnorrSignatureValid(pk.pk, hashedMsg, sig)
+}
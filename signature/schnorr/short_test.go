@@ -0,0 +1,139 @@
+package signature
+
+import (
+	"bytes"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+func TestShortSignAndVerify(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		sk := curve.SampleScalar()
+		pk := SchnorrPkFromSk(sk)
+		hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{g.SampleF(), g.SampleF()})
+
+		sig := SchnorrSignHashedMessageShort(hashedMsg, sk)
+		if !sig.IsCanonical() {
+			t.Fatalf("Short signature should be canonical")
+		}
+		if !IsShortSchnorrSignatureValid(pk, hashedMsg, sig) {
+			t.Fatalf("Short signature is invalid")
+		}
+
+		otherMsg := p2.HashToQuinticExtension([]g.GoldilocksField{g.SampleF()})
+		if IsShortSchnorrSignatureValid(pk, otherMsg, sig) {
+			t.Fatalf("Short signature should not verify for another message")
+		}
+		if IsShortSchnorrSignatureValid(SchnorrPkFromSk(curve.SampleScalar()), hashedMsg, sig) {
+			t.Fatalf("Short signature should not verify for another public key")
+		}
+		tampered := sig
+		tampered.S = tampered.S.Add(curve.ONE)
+		if IsShortSchnorrSignatureValid(pk, hashedMsg, tampered) {
+			t.Fatalf("Tampered short signature should be rejected")
+		}
+		tampered = sig
+		tampered.E[2] = 1
+		if IsShortSchnorrSignatureValid(pk, hashedMsg, tampered) {
+			t.Fatalf("Short signature with a challenge over 128 bits should be rejected")
+		}
+	}
+}
+
+func TestShortDeterministic(t *testing.T) {
+	sk := curve.SampleScalar()
+	hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{1, 2, 3})
+
+	sig := SchnorrSignHashedMessageShortDeterministic(hashedMsg, sk)
+	if sig != SchnorrSignHashedMessageShortDeterministic(hashedMsg, sk) {
+		t.Fatalf("Deterministic short signatures should be equal")
+	}
+	if !IsShortSchnorrSignatureValid(SchnorrPkFromSk(sk), hashedMsg, sig) {
+		t.Fatalf("Deterministic short signature is invalid")
+	}
+
+	// Both schemes must use distinct nonces: the same nonce with two
+	// challenges would reveal the secret key.
+	std := SchnorrSignHashedMessageDeterministic(hashedMsg, sk)
+	if curve.MulGen(sig.S).Add(curve.MulGen(sk).Mul(sig.E)).Equals(
+		curve.MulGen(std.S).Add(curve.MulGen(sk).Mul(std.E))) {
+		t.Fatalf("Short and standard signatures should not share nonces")
+	}
+}
+
+func TestShortAndStandardDoNotCrossVerify(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := SchnorrPkFromSk(sk)
+	hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{4, 5, 6})
+
+	short := SchnorrSignHashedMessageShort(hashedMsg, sk)
+	asStandard := Signature{S: short.S, E: short.E}
+	if IsSchnorrSignatureValid(pk, hashedMsg, asStandard) {
+		t.Fatalf("Short signature should not verify as a standard signature")
+	}
+
+	// A standard signature with a challenge below 2^128 is not a short
+	// signature either, since the challenges are domain-separated.
+	std := SchnorrSignHashedMessage(hashedMsg, sk)
+	asShort := ShortSignature{S: std.S, E: curve.ECgFp5Scalar{std.E[0], std.E[1]}}
+	if IsShortSchnorrSignatureValid(pk, hashedMsg, asShort) {
+		t.Fatalf("Standard signature should not verify as a short signature")
+	}
+}
+
+func TestShortBytes(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := SchnorrPkFromSk(sk)
+	hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{7})
+	sig := SchnorrSignHashedMessageShort(hashedMsg, sk)
+
+	b := sig.ToBytes()
+	if len(b) != ShortSignatureSize {
+		t.Fatalf("Expected %d bytes, got %d", ShortSignatureSize, len(b))
+	}
+	decoded, err := ShortSigFromBytes(b)
+	if err != nil || decoded != sig {
+		t.Fatalf("Short signature does not round-trip: %v", err)
+	}
+	if err := ValidateShort(pk.ToLittleEndianBytes(), hashedMsg.ToLittleEndianBytes(), b); err != nil {
+		t.Fatalf("ValidateShort failed: %v", err)
+	}
+	if _, err := ShortSigFromBytes(b[:55]); err == nil {
+		t.Fatalf("ShortSigFromBytes should reject short inputs")
+	}
+	nonCanonical := bytes.Clone(b)
+	copy(nonCanonical[:40], bytes.Repeat([]byte{0xff}, 40))
+	if _, err := ShortSigFromBytes(nonCanonical); err == nil {
+		t.Fatalf("ShortSigFromBytes should reject non-canonical s")
+	}
+}
+
+func TestPrivateKeySignShort(t *testing.T) {
+	key, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{8, 9})
+	sig := key.SignHashedMessageShort(hashedMsg)
+	if !key.PublicKey().VerifyShort(hashedMsg, sig) {
+		t.Fatalf("Short signature is invalid")
+	}
+}
+
+// Compare with BenchmarkSignatureVerify.
+func BenchmarkShortSignatureVerify(b *testing.B) {
+	sk := curve.SampleScalar()
+	pk := SchnorrPkFromSk(sk)
+	hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{1, 2, 3})
+	sig := SchnorrSignHashedMessageShort(hashedMsg, sk)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !IsShortSchnorrSignatureValid(pk, hashedMsg, sig) {
+			b.Fatalf("Short signature is invalid")
+		}
+	}
+}
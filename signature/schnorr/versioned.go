@@ -0,0 +1,123 @@
+package signature
+
+import (
+	"errors"
+	"fmt"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+)
+
+// SignatureVersion identifies a signature scheme in versioned encodings,
+// which are the encoding of the signature prefixed with its version byte.
+// The unversioned 80-byte encoding of standard signatures is unchanged.
+type SignatureVersion uint8
+
+const (
+	// Standard signatures, with a 320-bit challenge (81 bytes).
+	SIG_VERSION_STANDARD SignatureVersion = 0x01
+	// Short signatures, with a 128-bit challenge (57 bytes).
+	SIG_VERSION_SHORT SignatureVersion = 0x02
+)
+
+// VersionedSignature is a signature of one of the versioned schemes:
+// Signature or ShortSignature.
+type VersionedSignature interface {
+	Version() SignatureVersion
+	ToBytes() []byte
+	VersionedBytes() []byte
+	verify(pubKey, hashedMsg gFp5.Element) bool
+}
+
+var (
+	_ VersionedSignature = Signature{}
+	_ VersionedSignature = ShortSignature{}
+)
+
+func (s Signature) Version() SignatureVersion {
+	return SIG_VERSION_STANDARD
+}
+
+// VersionedBytes returns SIG_VERSION_STANDARD || ToBytes().
+func (s Signature) VersionedBytes() []byte {
+	return append([]byte{byte(SIG_VERSION_STANDARD)}, s.ToBytes()...)
+}
+
+func (s Signature) verify(pubKey, hashedMsg gFp5.Element) bool {
+	return IsSchnorrSignatureValid(pubKey, hashedMsg, s)
+}
+
+func (s ShortSignature) Version() SignatureVersion {
+	return SIG_VERSION_SHORT
+}
+
+// VersionedBytes returns SIG_VERSION_SHORT || ToBytes().
+func (s ShortSignature) VersionedBytes() []byte {
+	return append([]byte{byte(SIG_VERSION_SHORT)}, s.ToBytes()...)
+}
+
+func (s ShortSignature) verify(pubKey, hashedMsg gFp5.Element) bool {
+	return IsShortSchnorrSignatureValid(pubKey, hashedMsg, s)
+}
+
+// ParseSignature decodes a versioned signature encoding.
+func ParseSignature(b []byte) (VersionedSignature, error) {
+	if len(b) == 0 {
+		return nil, errors.New("empty signature")
+	}
+	var (
+		sig VersionedSignature
+		err error
+	)
+	switch SignatureVersion(b[0]) {
+	case SIG_VERSION_STANDARD:
+		sig, err = SigFromBytes(b[1:])
+	case SIG_VERSION_SHORT:
+		sig, err = ShortSigFromBytes(b[1:])
+	default:
+		return nil, fmt.Errorf("unknown signature version 0x%02x", b[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// SignVersioned signs the hashed message with a hedged nonce, using the
+// scheme of the provided version.
+func SignVersioned(version SignatureVersion, hashedMsg gFp5.Element, sk curve.ECgFp5Scalar) (VersionedSignature, error) {
+	switch version {
+	case SIG_VERSION_STANDARD:
+		return SchnorrSignHashedMessage(hashedMsg, sk), nil
+	case SIG_VERSION_SHORT:
+		return SchnorrSignHashedMessageShort(hashedMsg, sk), nil
+	default:
+		return nil, fmt.Errorf("unknown signature version 0x%02x", uint8(version))
+	}
+}
+
+// VerifyVersioned checks a signature with the scheme of its version.
+func VerifyVersioned(pubKey, hashedMsg gFp5.Element, sig VersionedSignature) bool {
+	return sig != nil && sig.verify(pubKey, hashedMsg)
+}
+
+// ValidateVersioned is Validate() for versioned signature encodings.
+func ValidateVersioned(pubKey, hashedMsg, sig []byte) error {
+	pk, err := gFp5.FromCanonicalLittleEndianBytes(pubKey)
+	if err != nil {
+		return fmt.Errorf("failed to convert public key bytes to field element: %w", err)
+	}
+	hashedMsgElem, err := gFp5.FromCanonicalLittleEndianBytes(hashedMsg)
+	if err != nil {
+		return fmt.Errorf("failed to convert hashed message bytes to field element: %w", err)
+	}
+	s, err := ParseSignature(sig)
+	if err != nil {
+		return fmt.Errorf("failed to convert signature bytes to Schnorr signature: %w", err)
+	}
+
+	if !VerifyVersioned(pk, hashedMsgElem, s) {
+		return errors.New("signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package signature
+
+import (
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+func TestVersionedSignatures(t *testing.T) {
+	sk := curve.SampleScalar()
+	pk := SchnorrPkFromSk(sk)
+	hashedMsg := p2.HashToQuinticExtension([]g.GoldilocksField{1, 2, 3})
+
+	for _, tc := range []struct {
+		version SignatureVersion
+		size    int
+	}{
+		{SIG_VERSION_STANDARD, 81},
+		{SIG_VERSION_SHORT, 57},
+	} {
+		sig, err := SignVersioned(tc.version, hashedMsg, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig.Version() != tc.version {
+			t.Fatalf("Expected version %d, got %d", tc.version, sig.Version())
+		}
+		b := sig.VersionedBytes()
+		if len(b) != tc.size || b[0] != byte(tc.version) {
+			t.Fatalf("Unexpected versioned encoding for version %d", tc.version)
+		}
+
+		parsed, err := ParseSignature(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != sig {
+			t.Fatalf("Versioned signature does not round-trip")
+		}
+		if !VerifyVersioned(pk, hashedMsg, parsed) {
+			t.Fatalf("Versioned signature is invalid")
+		}
+		if err := ValidateVersioned(pk.ToLittleEndianBytes(), hashedMsg.ToLittleEndianBytes(), b); err != nil {
+			t.Fatalf("ValidateVersioned failed: %v", err)
+		}
+
+		// The version byte selects the decoder, so it cannot be swapped.
+		swapped := append([]byte{byte(SIG_VERSION_STANDARD + SIG_VERSION_SHORT - tc.version)}, b[1:]...)
+		if _, err := ParseSignature(swapped); err == nil {
+			t.Fatalf("ParseSignature should reject a mismatched version byte")
+		}
+	}
+
+	// The unversioned standard encoding is unchanged.
+	std := SchnorrSignHashedMessage(hashedMsg, sk)
+	if len(std.ToBytes()) != 80 {
+		t.Fatalf("Standard encoding should remain 80 bytes")
+	}
+
+	if _, err := ParseSignature(nil); err == nil {
+		t.Fatalf("ParseSignature should reject empty inputs")
+	}
+	if _, err := ParseSignature(append([]byte{0x03}, std.ToBytes()...)); err == nil {
+		t.Fatalf("ParseSignature should reject unknown versions")
+	}
+	if _, err := SignVersioned(0, hashedMsg, sk); err == nil {
+		t.Fatalf("SignVersioned should reject unknown versions")
+	}
+	if VerifyVersioned(pk, hashedMsg, nil) {
+		t.Fatalf("VerifyVersioned should reject nil signatures")
+	}
+}
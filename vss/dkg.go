@@ -0,0 +1,541 @@
+package vss
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+)
+
+// Distributed key generation of Gennaro, Jarecki, Krawczyk and Rabin
+// ("Secure Distributed Key Generation for Discrete-Log Based
+// Cryptosystems", 2007). Each participant deals a Pedersen sharing of a
+// random secret; the group secret key is the sum of the secrets of the
+// qualified dealers, and nobody learns it. Unlike a plain Feldman DKG, the
+// group public key is uniformly distributed even if some participants
+// choose their secrets after seeing the commitments of the others.
+//
+// The protocol runs in synchronous rounds. Each round consumes the
+// messages of the previous round and produces the messages of the next
+// one; broadcast messages must be delivered to all participants over a
+// reliable broadcast channel, and PrivateShareMessage values over
+// confidential and authenticated channels. A participant whose message
+// is missing is handled as described for each round.
+//
+//  1. Deal(): commit to the secret and blinding polynomials (broadcast),
+//     and send a share to each other participant (private).
+//  2. Complain(): broadcast complaints against the dealers whose share is
+//     missing or invalid. Dealers without a commitment are excluded.
+//  3. Justify(): each dealer publishes the shares of the participants who
+//     complained against it.
+//  4. Qualify(): dealers with too many complaints, or whose justification
+//     is missing or invalid, are disqualified; the others form the
+//     qualified set QUAL. Each qualified dealer publishes the Feldman
+//     commitment to its secret polynomial.
+//  5. CheckExtraction(): each participant checks its shares against the
+//     Feldman commitments, and publishes the failing ones as evidence.
+//  6. Reveal(): the secrets of the dealers with a missing Feldman
+//     commitment or a valid complaint are reconstructed in the open:
+//     each participant publishes its share of them.
+//  7. Finalize(): compute the secret share, the group public key and the
+//     verification shares.
+//
+// All honest participants obtain the same qualified set and public key
+// as long as fewer than threshold participants misbehave, with
+// 2*threshold - 1 <= n.
+
+// DKG rounds.
+type DKGRound int
+
+const (
+	DKG_DEAL DKGRound = iota
+	DKG_COMPLAIN
+	DKG_JUSTIFY
+	DKG_QUALIFY
+	DKG_CHECK_EXTRACTION
+	DKG_REVEAL
+	DKG_FINALIZE
+	DKG_DONE
+)
+
+// DealMessage is broadcast by each dealer in round 1.
+type DealMessage struct {
+	From       uint32
+	Commitment PedersenCommitment
+}
+
+// PrivateShareMessage is the share sent by dealer From to participant To
+// in round 1. It is a secret value.
+type PrivateShareMessage struct {
+	From, To uint32
+	Share    PedersenShare
+}
+
+// ComplaintMessage is broadcast by each participant in round 2: the
+// dealers whose share was missing or invalid.
+type ComplaintMessage struct {
+	From    uint32
+	Against []uint32
+}
+
+// JustificationMessage is broadcast by each dealer in round 3: the shares
+// of the participants who complained against it.
+type JustificationMessage struct {
+	From   uint32
+	Shares []PedersenShare
+}
+
+// ExtractionMessage is broadcast by each qualified dealer in round 4: the
+// Feldman commitment to its secret polynomial.
+type ExtractionMessage struct {
+	From       uint32
+	Commitment Commitment
+}
+
+// ExtractionEvidence proves that a Feldman commitment is inconsistent
+// with the Pedersen commitment of the same dealer: the share is valid
+// for the latter, and invalid for the former.
+type ExtractionEvidence struct {
+	Against uint32
+	Share   PedersenShare
+}
+
+// ExtractionComplaintMessage is broadcast by each participant in round 5.
+type ExtractionComplaintMessage struct {
+	From     uint32
+	Evidence []ExtractionEvidence
+}
+
+// RevealMessage is broadcast by each participant in round 6: its shares
+// of the dealers whose secret is reconstructed in the open.
+type RevealMessage struct {
+	From   uint32
+	Shares map[uint32]PedersenShare
+}
+
+// DKGResult is the outcome of the key generation for a participant. The
+// secret share is a secret value.
+type DKGResult struct {
+	ID                 uint32
+	Threshold          int
+	Qualified          []uint32
+	SecretShare        curve.ECgFp5Scalar
+	PublicKey          curve.ECgFp5Point
+	VerificationShares map[uint32]curve.ECgFp5Point
+}
+
+// DKGParticipant is the state of a participant during the key
+// generation. It holds secret values.
+type DKGParticipant struct {
+	id        uint32
+	threshold int
+	n         int
+	round     DKGRound
+
+	// Secret and blinding polynomials of this participant as a dealer.
+	poly, blinding Polynomial
+	// Pedersen commitment of each dealer.
+	deals map[uint32]PedersenCommitment
+	// Valid share received from each dealer.
+	shares map[uint32]PedersenShare
+	// Participants who complained against each dealer.
+	complaints map[uint32][]uint32
+	// Qualified dealers, sorted.
+	qual []uint32
+	// Feldman commitments of the qualified dealers.
+	extractions map[uint32]Commitment
+	// Qualified dealers whose secret is reconstructed in the open.
+	exposed map[uint32]bool
+}
+
+// NewDKGParticipant creates the state of participant id among
+// participants 1 to n, with threshold t.
+func NewDKGParticipant(id uint32, threshold, n int) (*DKGParticipant, error) {
+	if threshold < 1 || threshold > n {
+		return nil, errors.New("threshold must be between 1 and the number of participants")
+	}
+	if id == 0 || uint64(id) > uint64(n) { //nolint:gosec
+		return nil, errors.New("participant identifier must be between 1 and the number of participants")
+	}
+	return &DKGParticipant{
+		id:          id,
+		threshold:   threshold,
+		n:           n,
+		round:       DKG_DEAL,
+		poly:        nil,
+		blinding:    nil,
+		deals:       make(map[uint32]PedersenCommitment),
+		shares:      make(map[uint32]PedersenShare),
+		complaints:  make(map[uint32][]uint32),
+		qual:        nil,
+		extractions: make(map[uint32]Commitment),
+		exposed:     make(map[uint32]bool),
+	}, nil
+}
+
+// ID returns the participant identifier.
+func (p *DKGParticipant) ID() uint32 {
+	return p.id
+}
+
+// Round returns the next round to run.
+func (p *DKGParticipant) Round() DKGRound {
+	return p.round
+}
+
+func (p *DKGParticipant) enter(round DKGRound) error {
+	if p.round != round {
+		return fmt.Errorf("unexpected DKG round %d, expected %d", round, p.round)
+	}
+	p.round++
+	return nil
+}
+
+// Check the sender of a message, and that it is not a duplicate.
+func (p *DKGParticipant) checkSender(from uint32, seen map[uint32]bool) error {
+	if from == 0 || uint64(from) > uint64(p.n) { //nolint:gosec
+		return fmt.Errorf("invalid sender %d", from)
+	}
+	if seen[from] {
+		return fmt.Errorf("duplicate message from participant %d", from)
+	}
+	seen[from] = true
+	return nil
+}
+
+// Deal runs round 1: it returns the commitment to broadcast and the share
+// to send to each other participant. The polynomials are sampled from rand
+// (or crypto/rand if rand is nil); if rand fails, Deal can be run again.
+func (p *DKGParticipant) Deal(rand io.Reader) (*DealMessage, []*PrivateShareMessage, error) {
+	poly, err := newSecretPolynomial(p.threshold-1, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	blinding, err := newSecretPolynomial(p.threshold-1, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p.enter(DKG_DEAL); err != nil {
+		return nil, nil, err
+	}
+	p.poly, p.blinding = poly, blinding
+	commitment, _ := CommitPedersen(p.poly, p.blinding)
+
+	p.shares[p.id] = pedersenShare(p.poly, p.blinding, p.id)
+	msgs := make([]*PrivateShareMessage, 0, p.n-1)
+	for j := uint32(1); uint64(j) <= uint64(p.n); j++ { //nolint:gosec
+		if j != p.id {
+			msgs = append(msgs, &PrivateShareMessage{From: p.id, To: j, Share: pedersenShare(p.poly, p.blinding, j)})
+		}
+	}
+	return &DealMessage{From: p.id, Commitment: commitment}, msgs, nil
+}
+
+// Complain runs round 2 on the broadcast deals (including the one of
+// this participant) and the shares addressed to this participant. Dealers without a well-formed commitment are
+// excluded; the others are accused if their share is missing or invalid.
+func (p *DKGParticipant) Complain(deals []*DealMessage, shares []*PrivateShareMessage) (*ComplaintMessage, error) {
+	if err := p.enter(DKG_COMPLAIN); err != nil {
+		return nil, err
+	}
+	seen := make(map[uint32]bool, len(deals))
+	for _, m := range deals {
+		if err := p.checkSender(m.From, seen); err != nil {
+			return nil, err
+		}
+		if len(m.Commitment) == p.threshold {
+			p.deals[m.From] = m.Commitment
+		}
+	}
+
+	seen = make(map[uint32]bool, len(shares))
+	for _, m := range shares {
+		if err := p.checkSender(m.From, seen); err != nil {
+			return nil, err
+		}
+		if m.To != p.id {
+			return nil, fmt.Errorf("share from participant %d is addressed to participant %d", m.From, m.To)
+		}
+		c, ok := p.deals[m.From]
+		if m.From != p.id && ok && m.Share.Index == p.id && c.Verify(m.Share) {
+			p.shares[m.From] = m.Share
+		}
+	}
+
+	msg := &ComplaintMessage{From: p.id, Against: nil}
+	for _, dealer := range sortedKeys(p.deals) {
+		if _, ok := p.shares[dealer]; !ok {
+			msg.Against = append(msg.Against, dealer)
+		}
+	}
+	return msg, nil
+}
+
+// Justify runs round 3 on the broadcast complaints: it returns the shares
+// of the participants who complained against this dealer.
+func (p *DKGParticipant) Justify(complaints []*ComplaintMessage) (*JustificationMessage, error) {
+	if err := p.enter(DKG_JUSTIFY); err != nil {
+		return nil, err
+	}
+	seen := make(map[uint32]bool, len(complaints))
+	for _, m := range complaints {
+		if err := p.checkSender(m.From, seen); err != nil {
+			return nil, err
+		}
+		accused := make(map[uint32]bool, len(m.Against))
+		for _, dealer := range m.Against {
+			if _, ok := p.deals[dealer]; ok && dealer != m.From && !accused[dealer] {
+				accused[dealer] = true
+				p.complaints[dealer] = append(p.complaints[dealer], m.From)
+			}
+		}
+	}
+
+	msg := &JustificationMessage{From: p.id, Shares: nil}
+	for _, j := range p.complaints[p.id] {
+		msg.Shares = append(msg.Shares, pedersenShare(p.poly, p.blinding, j))
+	}
+	return msg, nil
+}
+
+// Qualify runs round 4 on the broadcast justifications. A dealer is
+// disqualified if it received threshold complaints or more, or if it did
+// not publish a valid share for each complaint. This participant adopts
+// the published shares of the dealers it complained against.
+//
+// The returned message is nil if this participant is not qualified.
+func (p *DKGParticipant) Qualify(justifications []*JustificationMessage) (*ExtractionMessage, error) {
+	if err := p.enter(DKG_QUALIFY); err != nil {
+		return nil, err
+	}
+	byDealer := make(map[uint32]*JustificationMessage, len(justifications))
+	seen := make(map[uint32]bool, len(justifications))
+	for _, m := range justifications {
+		if err := p.checkSender(m.From, seen); err != nil {
+			return nil, err
+		}
+		byDealer[m.From] = m
+	}
+
+	for _, dealer := range sortedKeys(p.deals) {
+		if p.justified(dealer, byDealer[dealer]) {
+			p.qual = append(p.qual, dealer)
+		}
+	}
+	if len(p.qual) == 0 {
+		return nil, errors.New("no qualified dealer")
+	}
+	if !p.isQualified(p.id) {
+		return nil, nil
+	}
+	return &ExtractionMessage{From: p.id, Commitment: p.poly.Commit()}, nil
+}
+
+// Check the justification of a dealer, and adopt the published share of
+// this participant if there is one.
+func (p *DKGParticipant) justified(dealer uint32, m *JustificationMessage) bool {
+	complainers := p.complaints[dealer]
+	if len(complainers) == 0 {
+		return true
+	}
+	if len(complainers) >= p.threshold || m == nil {
+		return false
+	}
+	c := p.deals[dealer]
+	published := make(map[uint32]PedersenShare, len(m.Shares))
+	for _, s := range m.Shares {
+		if c.Verify(s) {
+			published[s.Index] = s
+		}
+	}
+	for _, j := range complainers {
+		if _, ok := published[j]; !ok {
+			return false
+		}
+	}
+	if s, ok := published[p.id]; ok {
+		p.shares[dealer] = s
+	}
+	return true
+}
+
+func (p *DKGParticipant) isQualified(dealer uint32) bool {
+	i := sort.Search(len(p.qual), func(i int) bool { return p.qual[i] >= dealer })
+	return i < len(p.qual) && p.qual[i] == dealer
+}
+
+// CheckExtraction runs round 5 on the broadcast Feldman commitments. The
+// qualified dealers whose commitment is missing or malformed are exposed;
+// those whose commitment does not match the share of this participant
+// are accused, with the share as evidence.
+func (p *DKGParticipant) CheckExtraction(extractions []*ExtractionMessage) (*ExtractionComplaintMessage, error) {
+	if err := p.enter(DKG_CHECK_EXTRACTION); err != nil {
+		return nil, err
+	}
+	seen := make(map[uint32]bool, len(extractions))
+	for _, m := range extractions {
+		if err := p.checkSender(m.From, seen); err != nil {
+			return nil, err
+		}
+		if p.isQualified(m.From) && len(m.Commitment) == p.threshold {
+			p.extractions[m.From] = m.Commitment
+		}
+	}
+
+	msg := &ExtractionComplaintMessage{From: p.id, Evidence: nil}
+	for _, dealer := range p.qual {
+		c, ok := p.extractions[dealer]
+		if !ok {
+			p.exposed[dealer] = true
+			continue
+		}
+		s := p.shares[dealer]
+		if dealer != p.id && !c.Verify(s.Share()) {
+			p.exposed[dealer] = true
+			msg.Evidence = append(msg.Evidence, ExtractionEvidence{Against: dealer, Share: s})
+		}
+	}
+	return msg, nil
+}
+
+// Reveal runs round 6 on the broadcast extraction complaints. Valid
+// evidence exposes the accused dealer; invalid evidence is ignored. The
+// returned message holds the shares of this participant for all exposed
+// dealers.
+func (p *DKGParticipant) Reveal(complaints []*ExtractionComplaintMessage) (*RevealMessage, error) {
+	if err := p.enter(DKG_REVEAL); err != nil {
+		return nil, err
+	}
+	seen := make(map[uint32]bool, len(complaints))
+	for _, m := range complaints {
+		if err := p.checkSender(m.From, seen); err != nil {
+			return nil, err
+		}
+		for _, ev := range m.Evidence {
+			if p.validEvidence(m.From, ev) {
+				p.exposed[ev.Against] = true
+			}
+		}
+	}
+
+	msg := &RevealMessage{From: p.id, Shares: make(map[uint32]PedersenShare, len(p.exposed))}
+	for dealer := range p.exposed {
+		if s, ok := p.shares[dealer]; ok {
+			msg.Shares[dealer] = s
+		}
+	}
+	return msg, nil
+}
+
+func (p *DKGParticipant) validEvidence(from uint32, ev ExtractionEvidence) bool {
+	c, ok := p.extractions[ev.Against]
+	if !ok || ev.Share.Index != from {
+		return false
+	}
+	return p.deals[ev.Against].Verify(ev.Share) && !c.Verify(ev.Share.Share())
+}
+
+// Finalize runs round 7 on the broadcast reveals: the secrets of the
+// exposed dealers are reconstructed from at least threshold valid shares,
+// and the key material is computed. The secret polynomials are erased.
+func (p *DKGParticipant) Finalize(reveals []*RevealMessage) (*DKGResult, error) {
+	if err := p.enter(DKG_FINALIZE); err != nil {
+		return nil, err
+	}
+	revealed := make(map[uint32][]Share, len(p.exposed))
+	seen := make(map[uint32]bool, len(reveals))
+	for _, m := range reveals {
+		if err := p.checkSender(m.From, seen); err != nil {
+			return nil, err
+		}
+		for dealer, s := range m.Shares {
+			if p.exposed[dealer] && s.Index == m.From && p.deals[dealer].Verify(s) {
+				revealed[dealer] = append(revealed[dealer], s.Share())
+			}
+		}
+	}
+
+	res := &DKGResult{
+		ID:                 p.id,
+		Threshold:          p.threshold,
+		Qualified:          append([]uint32(nil), p.qual...),
+		SecretShare:        curve.ZERO,
+		PublicKey:          curve.NEUTRAL_ECgFp5Point,
+		VerificationShares: make(map[uint32]curve.ECgFp5Point, p.n),
+	}
+
+	// Sum of the Feldman commitments of the dealers which are not exposed,
+	// and the public values of the shares of the exposed dealers.
+	var sum Commitment
+	exposedValues := make([]curve.ECgFp5Scalar, p.n+1)
+	for i := range exposedValues {
+		exposedValues[i] = curve.ZERO
+	}
+	for _, dealer := range p.qual {
+		res.SecretShare = res.SecretShare.Add(p.shares[dealer].Value)
+		if !p.exposed[dealer] {
+			if sum == nil {
+				sum = p.extractions[dealer]
+			} else {
+				sum, _ = sum.Add(p.extractions[dealer])
+			}
+			continue
+		}
+
+		shares := revealed[dealer]
+		if len(shares) < p.threshold {
+			return nil, fmt.Errorf("not enough shares to reconstruct the secret of dealer %d", dealer)
+		}
+		shares = shares[:p.threshold]
+		for x := range exposedValues {
+			v, err := Interpolate(shares, uint32(x)) //nolint:gosec
+			if err != nil {
+				return nil, err
+			}
+			exposedValues[x] = exposedValues[x].Add(v)
+		}
+	}
+
+	res.PublicKey = curve.MulGen(exposedValues[0])
+	if sum != nil {
+		res.PublicKey = res.PublicKey.Add(sum[0])
+	}
+	for j := 1; j <= p.n; j++ {
+		vs := curve.MulGen(exposedValues[j])
+		if sum != nil {
+			vs = vs.Add(sum.Evaluate(uint32(j))) //nolint:gosec
+		}
+		res.VerificationShares[uint32(j)] = vs //nolint:gosec
+	}
+	if !curve.MulGen(res.SecretShare).Equals(res.VerificationShares[p.id]) {
+		return nil, errors.New("secret share does not match the verification share")
+	}
+	if res.PublicKey.IsNeutral() {
+		return nil, errors.New("group public key is the neutral element")
+	}
+
+	p.erase()
+	return res, nil
+}
+
+// Erase the secret values held by the participant.
+func (p *DKGParticipant) erase() {
+	for i := range p.poly {
+		p.poly[i], p.blinding[i] = curve.ZERO, curve.ZERO
+	}
+	p.poly, p.blinding = nil, nil
+	for dealer := range p.shares {
+		delete(p.shares, dealer)
+	}
+}
+
+func sortedKeys[V any](m map[uint32]V) []uint32 {
+	keys := make([]uint32, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,301 @@
+package vss
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+)
+
+// Hooks to tamper with the messages of each round; they may modify or
+// drop (set to nil) messages.
+type dkgTamper struct {
+	deal          func(deals []*DealMessage, shares []*PrivateShareMessage)
+	justify       func(justifications []*JustificationMessage)
+	extract       func(extractions []*ExtractionMessage)
+	checkExtract  func(complaints []*ExtractionComplaintMessage)
+	honestResults []uint32
+}
+
+func nonNil[T any](msgs []*T) []*T {
+	res := make([]*T, 0, len(msgs))
+	for _, m := range msgs {
+		if m != nil {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// Run the key generation between simulated participants, and return the
+// results of the participants listed in tamper.honestResults (or of all
+// participants).
+func runDKG(t *testing.T, threshold, n int, tamper dkgTamper) []*DKGResult {
+	t.Helper()
+	ps := make([]*DKGParticipant, n)
+	for i := range ps {
+		p, err := NewDKGParticipant(uint32(i+1), threshold, n) //nolint:gosec
+		if err != nil {
+			t.Fatalf("NewDKGParticipant failed: %v", err)
+		}
+		ps[i] = p
+	}
+
+	deals := make([]*DealMessage, n)
+	var private []*PrivateShareMessage
+	for i, p := range ps {
+		d, shares, err := p.Deal(nil)
+		if err != nil {
+			t.Fatalf("Deal failed: %v", err)
+		}
+		deals[i] = d
+		private = append(private, shares...)
+	}
+	if tamper.deal != nil {
+		tamper.deal(deals, private)
+	}
+	deals = nonNil(deals)
+	private = nonNil(private)
+
+	complaints := make([]*ComplaintMessage, n)
+	for i, p := range ps {
+		var mine []*PrivateShareMessage
+		for _, m := range private {
+			if m.To == p.ID() {
+				mine = append(mine, m)
+			}
+		}
+		c, err := p.Complain(deals, mine)
+		if err != nil {
+			t.Fatalf("Complain failed: %v", err)
+		}
+		complaints[i] = c
+	}
+
+	justifications := make([]*JustificationMessage, n)
+	for i, p := range ps {
+		j, err := p.Justify(complaints)
+		if err != nil {
+			t.Fatalf("Justify failed: %v", err)
+		}
+		justifications[i] = j
+	}
+	if tamper.justify != nil {
+		tamper.justify(justifications)
+	}
+	justifications = nonNil(justifications)
+
+	extractions := make([]*ExtractionMessage, n)
+	for i, p := range ps {
+		e, err := p.Qualify(justifications)
+		if err != nil {
+			t.Fatalf("Qualify failed: %v", err)
+		}
+		extractions[i] = e
+	}
+	if tamper.extract != nil {
+		tamper.extract(extractions)
+	}
+	extractions = nonNil(extractions)
+
+	extComplaints := make([]*ExtractionComplaintMessage, n)
+	for i, p := range ps {
+		c, err := p.CheckExtraction(extractions)
+		if err != nil {
+			t.Fatalf("CheckExtraction failed: %v", err)
+		}
+		extComplaints[i] = c
+	}
+	if tamper.checkExtract != nil {
+		tamper.checkExtract(extComplaints)
+	}
+
+	reveals := make([]*RevealMessage, n)
+	for i, p := range ps {
+		r, err := p.Reveal(extComplaints)
+		if err != nil {
+			t.Fatalf("Reveal failed: %v", err)
+		}
+		reveals[i] = r
+	}
+
+	ids := tamper.honestResults
+	if ids == nil {
+		for _, p := range ps {
+			ids = append(ids, p.ID())
+		}
+	}
+	results := make([]*DKGResult, 0, len(ids))
+	for _, id := range ids {
+		res, err := ps[id-1].Finalize(reveals)
+		if err != nil {
+			t.Fatalf("Finalize failed for participant %d: %v", id, err)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// Check that all results agree, and that the secret shares interpolate
+// to the secret key of the group public key.
+func checkDKGResults(t *testing.T, results []*DKGResult, qualified []uint32) {
+	t.Helper()
+	shares := make([]Share, len(results))
+	for i, res := range results {
+		if !reflect.DeepEqual(res.Qualified, qualified) {
+			t.Fatalf("participant %d: qualified set %v, want %v", res.ID, res.Qualified, qualified)
+		}
+		if !res.PublicKey.Equals(results[0].PublicKey) {
+			t.Fatalf("participant %d has a different public key", res.ID)
+		}
+		for id, vs := range results[0].VerificationShares {
+			if !vs.Equals(res.VerificationShares[id]) {
+				t.Fatalf("participant %d has a different verification share for %d", res.ID, id)
+			}
+		}
+		shares[i] = Share{Index: res.ID, Value: res.SecretShare}
+	}
+
+	threshold := results[0].Threshold
+	for start := 0; start+threshold <= len(shares); start++ {
+		sk, err := Reconstruct(shares[start : start+threshold])
+		if err != nil {
+			t.Fatalf("Reconstruct failed: %v", err)
+		}
+		if !curve.MulGen(sk).Equals(results[0].PublicKey) {
+			t.Fatalf("shares do not reconstruct the group secret key")
+		}
+	}
+}
+
+func TestDKG(t *testing.T) {
+	for _, tc := range []struct{ threshold, n int }{{1, 1}, {1, 3}, {2, 3}, {3, 5}} {
+		results := runDKG(t, tc.threshold, tc.n, dkgTamper{})
+		var all []uint32
+		for i := 1; i <= tc.n; i++ {
+			all = append(all, uint32(i)) //nolint:gosec
+		}
+		checkDKGResults(t, results, all)
+	}
+}
+
+func TestDKGComplaints(t *testing.T) {
+	corrupt := func(private []*PrivateShareMessage, from, to uint32) {
+		for _, m := range private {
+			if m.From == from && m.To == to {
+				m.Share.Value = m.Share.Value.Add(curve.ONE)
+			}
+		}
+	}
+
+	// Dealer 2 sends an invalid share to participant 4, and justifies it:
+	// it stays qualified, and participant 4 adopts the published share.
+	results := runDKG(t, 3, 5, dkgTamper{
+		deal: func(_ []*DealMessage, private []*PrivateShareMessage) { corrupt(private, 2, 4) },
+	})
+	checkDKGResults(t, results, []uint32{1, 2, 3, 4, 5})
+
+	// Same, with an invalid justification: dealer 2 is disqualified.
+	results = runDKG(t, 3, 5, dkgTamper{
+		deal: func(_ []*DealMessage, private []*PrivateShareMessage) { corrupt(private, 2, 4) },
+		justify: func(js []*JustificationMessage) {
+			js[1].Shares[0].Blinding = js[1].Shares[0].Blinding.Add(curve.ONE)
+		},
+	})
+	checkDKGResults(t, results, []uint32{1, 3, 4, 5})
+
+	// Missing justification.
+	results = runDKG(t, 3, 5, dkgTamper{
+		deal:    func(_ []*DealMessage, private []*PrivateShareMessage) { corrupt(private, 5, 1) },
+		justify: func(js []*JustificationMessage) { js[4] = nil },
+	})
+	checkDKGResults(t, results, []uint32{1, 2, 3, 4})
+
+	// Too many complaints: dealer 1 sends invalid shares to threshold
+	// participants.
+	results = runDKG(t, 2, 4, dkgTamper{
+		deal: func(_ []*DealMessage, private []*PrivateShareMessage) {
+			corrupt(private, 1, 2)
+			corrupt(private, 1, 3)
+		},
+	})
+	checkDKGResults(t, results, []uint32{2, 3, 4})
+
+	// Dealer 3 does not deal, and its shares are dropped.
+	results = runDKG(t, 2, 4, dkgTamper{
+		deal: func(deals []*DealMessage, private []*PrivateShareMessage) {
+			deals[2] = nil
+			for i, m := range private {
+				if m.From == 3 {
+					private[i] = nil
+				}
+			}
+		},
+	})
+	checkDKGResults(t, results, []uint32{1, 2, 4})
+}
+
+func TestDKGExtraction(t *testing.T) {
+	// Dealer 2 publishes a Feldman commitment to another polynomial: it
+	// is exposed with evidence, and its secret is reconstructed, so the
+	// group key is unchanged.
+	results := runDKG(t, 3, 5, dkgTamper{
+		extract: func(es []*ExtractionMessage) {
+			poly, _ := NewRandomPolynomial(curve.SampleScalar(), 2, nil)
+			es[1].Commitment = poly.Commit()
+		},
+	})
+	checkDKGResults(t, results, []uint32{1, 2, 3, 4, 5})
+
+	// Missing Feldman commitment.
+	results = runDKG(t, 2, 3, dkgTamper{
+		extract: func(es []*ExtractionMessage) { es[0] = nil },
+	})
+	checkDKGResults(t, results, []uint32{1, 2, 3})
+
+	// Participant 3 accuses dealer 1 with invalid evidence: the complaint
+	// is ignored.
+	results = runDKG(t, 2, 3, dkgTamper{
+		checkExtract: func(cs []*ExtractionComplaintMessage) {
+			cs[2].Evidence = append(cs[2].Evidence, ExtractionEvidence{
+				Against: 1,
+				Share:   PedersenShare{Index: 3, Value: curve.ONE, Blinding: curve.ONE},
+			})
+		},
+	})
+	checkDKGResults(t, results, []uint32{1, 2, 3})
+}
+
+func TestDKGErrors(t *testing.T) {
+	if _, err := NewDKGParticipant(0, 2, 3); err == nil {
+		t.Fatalf("identifier 0 should be rejected")
+	}
+	if _, err := NewDKGParticipant(4, 2, 3); err == nil {
+		t.Fatalf("identifier larger than n should be rejected")
+	}
+	if _, err := NewDKGParticipant(1, 4, 3); err == nil {
+		t.Fatalf("threshold larger than n should be rejected")
+	}
+
+	p, _ := NewDKGParticipant(1, 2, 3)
+	if _, err := p.Justify(nil); err == nil {
+		t.Fatalf("running a round out of order should fail")
+	}
+	if _, _, err := p.Deal(bytes.NewReader(nil)); err == nil || p.Round() != DKG_DEAL {
+		t.Fatalf("Deal should report a failing random source and stay in round 1")
+	}
+	d, _, err := p.Deal(nil)
+	if err != nil {
+		t.Fatalf("Deal failed: %v", err)
+	}
+	if p.Round() != DKG_COMPLAIN {
+		t.Fatalf("unexpected round %d", p.Round())
+	}
+	if _, _, err := p.Deal(nil); err == nil {
+		t.Fatalf("dealing twice should fail")
+	}
+	if _, err := p.Complain([]*DealMessage{d, d}, nil); err == nil {
+		t.Fatalf("duplicate messages should be rejected")
+	}
+}
@@ -0,0 +1,153 @@
+package vss
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+)
+
+// PEDERSEN_GENERATOR is the second generator H of Pedersen commitments.
+// It is derived with HashToCurve(), so that its discrete logarithm in
+// base G is unknown.
+var PEDERSEN_GENERATOR = curve.HashToCurve(nil, []byte("vss-pedersen-generator"))
+
+// Precomputed comb for PEDERSEN_GENERATOR.
+var pedersenTable = curve.NewFixedBaseTable(PEDERSEN_GENERATOR)
+
+// PedersenShare is a share of a Pedersen sharing: the values at the
+// participant index of the sharing polynomial f and of the blinding
+// polynomial b. It is a secret value.
+type PedersenShare struct {
+	Index    uint32
+	Value    curve.ECgFp5Scalar
+	Blinding curve.ECgFp5Scalar
+}
+
+// Length of an encoded Pedersen share, in bytes.
+const PedersenShareSize = 4 + 40 + 40
+
+// PedersenCommitment is a Pedersen commitment to a polynomial f with
+// blinding polynomial b: C_k = f_k*G + b_k*H. Unlike a Commitment, it
+// reveals nothing about the secret f_0.
+type PedersenCommitment []curve.ECgFp5Point
+
+// CommitPedersen computes the Pedersen commitment to polynomial p with
+// the blinding polynomial, which must have the same length.
+func CommitPedersen(p, blinding Polynomial) (PedersenCommitment, error) {
+	if len(p) != len(blinding) {
+		return nil, errors.New("polynomial and blinding polynomial have different degrees")
+	}
+	c := make(PedersenCommitment, len(p))
+	for i := range p {
+		c[i] = curve.MulGen(p[i]).Add(pedersenTable.Mul(blinding[i]))
+	}
+	return c, nil
+}
+
+// SplitPedersen shares the secret between participants 1 to n, so that
+// any threshold of them can reconstruct it, and returns the shares with
+// the Pedersen commitment. The polynomials are sampled from rand (or
+// crypto/rand if rand is nil).
+func SplitPedersen(secret curve.ECgFp5Scalar, threshold, n int, rand io.Reader) ([]PedersenShare, PedersenCommitment, error) {
+	if threshold < 1 || threshold > n {
+		return nil, nil, errors.New("threshold must be between 1 and the number of participants")
+	}
+	if uint64(n) > uint64(^uint32(0)) {
+		return nil, nil, errors.New("too many participants")
+	}
+
+	p, err := NewRandomPolynomial(secret, threshold-1, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	blinding, err := newSecretPolynomial(threshold-1, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, _ := CommitPedersen(p, blinding)
+	shares := make([]PedersenShare, n)
+	for i := range shares {
+		shares[i] = pedersenShare(p, blinding, uint32(i+1)) //nolint:gosec
+	}
+	return shares, c, nil
+}
+
+// A random polynomial with a random constant coefficient.
+func newSecretPolynomial(degree int, rand io.Reader) (Polynomial, error) {
+	secret, err := curve.SampleScalarFrom(rand)
+	if err != nil {
+		return nil, err
+	}
+	return NewRandomPolynomial(secret, degree, rand)
+}
+
+func pedersenShare(p, blinding Polynomial, index uint32) PedersenShare {
+	x := IndexScalar(index)
+	return PedersenShare{Index: index, Value: p.Evaluate(x), Blinding: blinding.Evaluate(x)}
+}
+
+// Share returns the share without its blinding value, e.g. for
+// Reconstruct().
+func (s PedersenShare) Share() Share {
+	return Share{Index: s.Index, Value: s.Value}
+}
+
+// Evaluate computes f(x)*G + b(x)*H for the committed polynomials.
+func (c PedersenCommitment) Evaluate(index uint32) curve.ECgFp5Point {
+	return evaluatePoints(c, index)
+}
+
+// Verify checks a share against the commitment:
+// share.Value*G + share.Blinding*H must be equal to f(x)*G + b(x)*H.
+func (c PedersenCommitment) Verify(share PedersenShare) bool {
+	if share.Index == 0 || !share.Value.IsCanonical() || !share.Blinding.IsCanonical() {
+		return false
+	}
+	lhs := curve.MulGen(share.Value).Add(pedersenTable.Mul(share.Blinding))
+	return lhs.Equals(c.Evaluate(share.Index))
+}
+
+// Add returns the commitment to the sums of the committed polynomials,
+// which must have the same degree.
+func (c PedersenCommitment) Add(other PedersenCommitment) (PedersenCommitment, error) {
+	return addPoints(c, other)
+}
+
+// Bytes returns the concatenated 40-byte encodings of the points.
+func (c PedersenCommitment) Bytes() []byte {
+	return pointsBytes(c)
+}
+
+// PedersenCommitmentFromBytes decodes a commitment from its encoding.
+func PedersenCommitmentFromBytes(b []byte) (PedersenCommitment, error) {
+	return pointsFromBytes(b)
+}
+
+// Bytes returns the encoding index (4 bytes, little-endian) || value ||
+// blinding.
+func (s PedersenShare) Bytes() []byte {
+	res := make([]byte, 4, PedersenShareSize)
+	binary.LittleEndian.PutUint32(res, s.Index)
+	res = append(res, s.Value.ToLittleEndianBytes()...)
+	return append(res, s.Blinding.ToLittleEndianBytes()...)
+}
+
+// PedersenShareFromBytes decodes a share from its encoding.
+func PedersenShareFromBytes(b []byte) (PedersenShare, error) {
+	zero := PedersenShare{Index: 0, Value: curve.ZERO, Blinding: curve.ZERO}
+	if len(b) != PedersenShareSize {
+		return zero, errors.New("invalid share length, must be 84 bytes")
+	}
+	s, err := ShareFromBytes(b[:ShareSize])
+	if err != nil {
+		return zero, err
+	}
+	blinding, err := curve.ScalarFromCanonicalBytes(b[ShareSize:])
+	if err != nil {
+		return zero, fmt.Errorf("invalid share blinding: %w", err)
+	}
+	return PedersenShare{Index: s.Index, Value: s.Value, Blinding: blinding}, nil
+}
@@ -0,0 +1,278 @@
+// Package vss implements Shamir secret sharing over the scalar field of
+// the ECgFp5 group, with Feldman verifiable secret sharing: the dealer
+// publishes commitments to the coefficients of the sharing polynomial,
+// against which each participant checks its share. Feldman commitments
+// reveal secret*G; Pedersen verifiable secret sharing (pedersen.go) hides
+// the secret perfectly, and is the basis of the distributed key
+// generation of dkg.go.
+//
+// Participants are identified by non-zero 32-bit indices, which are the
+// points at which the polynomial is evaluated; the secret is the value at
+// zero.
+package vss
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+)
+
+// Share is the value of the sharing polynomial at the participant index.
+// It is a secret value.
+type Share struct {
+	Index uint32
+	Value curve.ECgFp5Scalar
+}
+
+// Length of an encoded share, in bytes.
+const ShareSize = 4 + 40
+
+// Polynomial is a polynomial over the scalars, with the constant
+// coefficient first. It is a secret value.
+type Polynomial []curve.ECgFp5Scalar
+
+// Commitment is a Feldman commitment to a polynomial: the coefficients
+// multiplied by the conventional generator. The first point is the
+// public value of the secret.
+type Commitment []curve.ECgFp5Point
+
+// IndexScalar returns the participant index as a scalar.
+func IndexScalar(index uint32) curve.ECgFp5Scalar {
+	return curve.ECgFp5Scalar{uint64(index), 0, 0, 0, 0}
+}
+
+// NewRandomPolynomial returns a random polynomial of the provided degree
+// (i.e. with degree+1 coefficients), whose constant coefficient is the
+// secret. The other coefficients are sampled from rand (or crypto/rand if
+// rand is nil).
+func NewRandomPolynomial(secret curve.ECgFp5Scalar, degree int, rand io.Reader) (Polynomial, error) {
+	p := make(Polynomial, degree+1)
+	p[0] = secret
+	for i := 1; i <= degree; i++ {
+		c, err := curve.SampleScalarFrom(rand)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = c
+	}
+	return p, nil
+}
+
+// Evaluate computes the value of the polynomial at x (Horner's rule).
+func (p Polynomial) Evaluate(x curve.ECgFp5Scalar) curve.ECgFp5Scalar {
+	res := curve.ZERO
+	for i := len(p) - 1; i >= 0; i-- {
+		res = res.Mul(x).Add(p[i])
+	}
+	return res
+}
+
+// Share returns the share of the participant with the provided index.
+func (p Polynomial) Share(index uint32) Share {
+	return Share{Index: index, Value: p.Evaluate(IndexScalar(index))}
+}
+
+// Commit computes the Feldman commitment to the polynomial.
+func (p Polynomial) Commit() Commitment {
+	c := make(Commitment, len(p))
+	for i := range p {
+		c[i] = curve.MulGen(p[i])
+	}
+	return c
+}
+
+// Evaluate computes f(x)*G for the committed polynomial f, i.e. the
+// public value of the share at index x.
+func (c Commitment) Evaluate(index uint32) curve.ECgFp5Point {
+	return evaluatePoints(c, index)
+}
+
+// Verify checks a share against the commitment: share.Value*G must be
+// equal to f(share.Index)*G.
+func (c Commitment) Verify(share Share) bool {
+	if share.Index == 0 || !share.Value.IsCanonical() {
+		return false
+	}
+	return curve.MulGen(share.Value).Equals(c.Evaluate(share.Index))
+}
+
+// Add returns the commitment to the sum of the committed polynomials,
+// which must have the same degree.
+func (c Commitment) Add(other Commitment) (Commitment, error) {
+	return addPoints(c, other)
+}
+
+// Bytes returns the concatenated 40-byte encodings of the points.
+func (c Commitment) Bytes() []byte {
+	return pointsBytes(c)
+}
+
+// CommitmentFromBytes decodes a commitment from its encoding.
+func CommitmentFromBytes(b []byte) (Commitment, error) {
+	return pointsFromBytes(b)
+}
+
+// Compute \sum_k x^k*C_k for the index x.
+func evaluatePoints(c []curve.ECgFp5Point, index uint32) curve.ECgFp5Point {
+	x := IndexScalar(index)
+	powers := make([]curve.ECgFp5Scalar, len(c))
+	xi := curve.ONE
+	for i := range powers {
+		powers[i] = xi
+		xi = xi.Mul(x)
+	}
+	return curve.MultiScalarMulVarTime(c, powers)
+}
+
+func addPoints(c, other []curve.ECgFp5Point) ([]curve.ECgFp5Point, error) {
+	if len(c) != len(other) {
+		return nil, errors.New("commitments have different degrees")
+	}
+	res := make([]curve.ECgFp5Point, len(c))
+	for i := range c {
+		res[i] = c[i].Add(other[i])
+	}
+	return res, nil
+}
+
+func pointsBytes(c []curve.ECgFp5Point) []byte {
+	res := make([]byte, 0, 40*len(c))
+	for _, p := range c {
+		res = append(res, p.ToBytes()...)
+	}
+	return res
+}
+
+func pointsFromBytes(b []byte) ([]curve.ECgFp5Point, error) {
+	if len(b) == 0 || len(b)%40 != 0 {
+		return nil, errors.New("invalid commitment length, must be a non-zero multiple of 40 bytes")
+	}
+	c := make([]curve.ECgFp5Point, len(b)/40)
+	for i := range c {
+		p, err := curve.PointFromBytes(b[40*i : 40*(i+1)])
+		if err != nil {
+			return nil, fmt.Errorf("invalid point %d: %w", i, err)
+		}
+		c[i] = p
+	}
+	return c, nil
+}
+
+// Bytes returns the encoding index (4 bytes, little-endian) || value.
+func (s Share) Bytes() []byte {
+	res := make([]byte, 4, ShareSize)
+	binary.LittleEndian.PutUint32(res, s.Index)
+	return append(res, s.Value.ToLittleEndianBytes()...)
+}
+
+// ShareFromBytes decodes a share from its encoding.
+func ShareFromBytes(b []byte) (Share, error) {
+	if len(b) != ShareSize {
+		return Share{Index: 0, Value: curve.ZERO}, errors.New("invalid share length, must be 44 bytes")
+	}
+	index := binary.LittleEndian.Uint32(b)
+	if index == 0 {
+		return Share{Index: 0, Value: curve.ZERO}, errors.New("invalid share index")
+	}
+	v, err := curve.ScalarFromCanonicalBytes(b[4:])
+	if err != nil {
+		return Share{Index: 0, Value: curve.ZERO}, fmt.Errorf("invalid share value: %w", err)
+	}
+	return Share{Index: index, Value: v}, nil
+}
+
+// Split shares the secret between participants 1 to n, so that any
+// threshold of them can reconstruct it, and returns the shares with the
+// Feldman commitment. The polynomial is sampled from rand (or crypto/rand
+// if rand is nil).
+func Split(secret curve.ECgFp5Scalar, threshold, n int, rand io.Reader) ([]Share, Commitment, error) {
+	if threshold < 1 || threshold > n {
+		return nil, nil, errors.New("threshold must be between 1 and the number of participants")
+	}
+	if uint64(n) > uint64(^uint32(0)) {
+		return nil, nil, errors.New("too many participants")
+	}
+
+	p, err := NewRandomPolynomial(secret, threshold-1, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = p.Share(uint32(i + 1)) //nolint:gosec
+	}
+	return shares, p.Commit(), nil
+}
+
+// LagrangeCoefficient computes the Lagrange coefficient of index i for
+// interpolation at zero from the provided set of indices:
+//
+//	\prod_{j != i} x_j/(x_j - x_i)
+//
+// The indices must be distinct and non-zero, and include i.
+func LagrangeCoefficient(i uint32, indices []uint32) (curve.ECgFp5Scalar, error) {
+	return LagrangeCoefficientAt(0, i, indices)
+}
+
+// LagrangeCoefficientAt computes the Lagrange coefficient of index i for
+// interpolation at x from the provided set of indices:
+//
+//	\prod_{j != i} (x - x_j)/(x_i - x_j)
+//
+// The indices must be distinct and non-zero, and include i.
+func LagrangeCoefficientAt(x, i uint32, indices []uint32) (curve.ECgFp5Scalar, error) {
+	xs, xi := IndexScalar(x), IndexScalar(i)
+	num, den := curve.ONE, curve.ONE
+	found := false
+	for _, j := range indices {
+		if j == 0 {
+			return curve.ZERO, errors.New("invalid index 0")
+		}
+		if j == i {
+			if found {
+				return curve.ZERO, errors.New("duplicate index")
+			}
+			found = true
+			continue
+		}
+		xj := IndexScalar(j)
+		num = num.Mul(xs.Sub(xj))
+		den = den.Mul(xi.Sub(xj))
+	}
+	if !found {
+		return curve.ZERO, errors.New("index is not in the set")
+	}
+	return num.Mul(den.Inverse()), nil
+}
+
+// Reconstruct interpolates the secret from shares with distinct indices;
+// at least threshold shares are needed to obtain the shared secret.
+func Reconstruct(shares []Share) (curve.ECgFp5Scalar, error) {
+	return Interpolate(shares, 0)
+}
+
+// Interpolate computes the value at x of the polynomial of lowest degree
+// which passes through the shares. With at least threshold shares, this
+// is the value of the sharing polynomial, i.e. the share of index x.
+func Interpolate(shares []Share, x uint32) (curve.ECgFp5Scalar, error) {
+	if len(shares) == 0 {
+		return curve.ZERO, errors.New("no shares")
+	}
+	indices := make([]uint32, len(shares))
+	for i := range shares {
+		indices[i] = shares[i].Index
+	}
+
+	res := curve.ZERO
+	for _, s := range shares {
+		l, err := LagrangeCoefficientAt(x, s.Index, indices)
+		if err != nil {
+			return curve.ZERO, err
+		}
+		res = res.Add(l.Mul(s.Value))
+	}
+	return res, nil
+}
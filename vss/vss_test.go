@@ -0,0 +1,261 @@
+package vss
+
+import (
+	"bytes"
+	"testing"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+)
+
+func TestSplitReconstruct(t *testing.T) {
+	for _, tc := range []struct{ threshold, n int }{{1, 1}, {1, 3}, {2, 3}, {3, 5}, {5, 5}} {
+		secret := curve.SampleScalar()
+		shares, commitment, err := Split(secret, tc.threshold, tc.n, nil)
+		if err != nil {
+			t.Fatalf("Split failed: %v", err)
+		}
+		if len(shares) != tc.n || len(commitment) != tc.threshold {
+			t.Fatalf("unexpected lengths %d, %d", len(shares), len(commitment))
+		}
+		if !commitment[0].Equals(curve.MulGen(secret)) {
+			t.Fatalf("commitment does not commit to the secret")
+		}
+		for _, s := range shares {
+			if !commitment.Verify(s) {
+				t.Fatalf("share %d is invalid", s.Index)
+			}
+		}
+
+		// Any threshold shares reconstruct the secret.
+		for start := 0; start+tc.threshold <= tc.n; start++ {
+			got, err := Reconstruct(shares[start : start+tc.threshold])
+			if err != nil {
+				t.Fatalf("Reconstruct failed: %v", err)
+			}
+			if !got.Equals(secret) {
+				t.Fatalf("threshold %d of %d: wrong secret from shares %d..", tc.threshold, tc.n, start+1)
+			}
+		}
+		if tc.threshold > 1 {
+			got, _ := Reconstruct(shares[:tc.threshold-1])
+			if got.Equals(secret) {
+				t.Fatalf("threshold %d of %d: fewer shares should not reconstruct the secret", tc.threshold, tc.n)
+			}
+		}
+	}
+
+	for _, tc := range []struct{ threshold, n int }{{0, 3}, {4, 3}, {1, 0}} {
+		if _, _, err := Split(curve.SampleScalar(), tc.threshold, tc.n, nil); err == nil {
+			t.Fatalf("Split(%d, %d) should fail", tc.threshold, tc.n)
+		}
+	}
+}
+
+func TestSplitRandomness(t *testing.T) {
+	// Two coefficients of 80 bytes each.
+	seed := bytes.Repeat([]byte{7}, 160)
+	secret := curve.SampleScalar()
+	_, c1, err := Split(secret, 3, 5, bytes.NewReader(seed))
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	_, c2, _ := Split(secret, 3, 5, bytes.NewReader(seed))
+	for i := range c1 {
+		if !c1[i].Equals(c2[i]) {
+			t.Fatalf("Split should be deterministic for a fixed random source")
+		}
+	}
+	if _, _, err := Split(secret, 3, 5, bytes.NewReader(seed[:80])); err == nil {
+		t.Fatalf("Split should report a failing random source")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	shares, commitment, err := Split(curve.SampleScalar(), 3, 5, nil)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+
+	bad := shares[1]
+	bad.Value = bad.Value.Add(curve.ONE)
+	if commitment.Verify(bad) {
+		t.Fatalf("modified share should be invalid")
+	}
+	if commitment.Verify(Share{Index: 1, Value: shares[1].Value}) {
+		t.Fatalf("share with the wrong index should be invalid")
+	}
+	if commitment.Verify(Share{Index: 0, Value: curve.ZERO}) {
+		t.Fatalf("share with index 0 should be invalid")
+	}
+
+	// Commitments are additive.
+	shares2, commitment2, _ := Split(curve.SampleScalar(), 3, 5, nil)
+	sum, err := commitment.Add(commitment2)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	for i := range shares {
+		s := Share{Index: shares[i].Index, Value: shares[i].Value.Add(shares2[i].Value)}
+		if !sum.Verify(s) {
+			t.Fatalf("summed share %d is invalid", s.Index)
+		}
+	}
+	if _, err := commitment.Add(commitment[:2]); err == nil {
+		t.Fatalf("adding commitments of different degrees should fail")
+	}
+}
+
+func TestLagrangeCoefficient(t *testing.T) {
+	// Coefficients for interpolation at zero sum to 1.
+	indices := []uint32{2, 5, 7}
+	sum := curve.ZERO
+	for _, i := range indices {
+		l, err := LagrangeCoefficient(i, indices)
+		if err != nil {
+			t.Fatalf("LagrangeCoefficient failed: %v", err)
+		}
+		sum = sum.Add(l)
+	}
+	if !sum.Equals(curve.ONE) {
+		t.Fatalf("Lagrange coefficients should sum to 1")
+	}
+
+	for _, tc := range []struct {
+		i       uint32
+		indices []uint32
+	}{
+		{1, []uint32{2, 3}},
+		{1, []uint32{1, 1, 2}},
+		{1, []uint32{0, 1}},
+	} {
+		if _, err := LagrangeCoefficient(tc.i, tc.indices); err == nil {
+			t.Fatalf("LagrangeCoefficient(%d, %v) should fail", tc.i, tc.indices)
+		}
+	}
+	if _, err := Reconstruct(nil); err == nil {
+		t.Fatalf("Reconstruct without shares should fail")
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	shares, commitment, _ := Split(curve.SampleScalar(), 3, 4, nil)
+
+	for _, s := range shares {
+		b := s.Bytes()
+		if len(b) != ShareSize {
+			t.Fatalf("unexpected share length %d", len(b))
+		}
+		got, err := ShareFromBytes(b)
+		if err != nil {
+			t.Fatalf("ShareFromBytes failed: %v", err)
+		}
+		if got.Index != s.Index || !got.Value.Equals(s.Value) {
+			t.Fatalf("share round trip failed")
+		}
+	}
+	if _, err := ShareFromBytes(make([]byte, ShareSize)); err == nil {
+		t.Fatalf("share with index 0 should be rejected")
+	}
+	if _, err := ShareFromBytes(make([]byte, ShareSize-1)); err == nil {
+		t.Fatalf("share with invalid length should be rejected")
+	}
+
+	b := commitment.Bytes()
+	got, err := CommitmentFromBytes(b)
+	if err != nil {
+		t.Fatalf("CommitmentFromBytes failed: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), b) {
+		t.Fatalf("commitment round trip failed")
+	}
+	for _, bad := range [][]byte{nil, b[:39], b[:len(b)-1]} {
+		if _, err := CommitmentFromBytes(bad); err == nil {
+			t.Fatalf("commitment of length %d should be rejected", len(bad))
+		}
+	}
+}
+
+func TestPedersen(t *testing.T) {
+	secret := curve.SampleScalar()
+	shares, commitment, err := SplitPedersen(secret, 3, 5, nil)
+	if err != nil {
+		t.Fatalf("SplitPedersen failed: %v", err)
+	}
+	if commitment[0].Equals(curve.MulGen(secret)) {
+		t.Fatalf("Pedersen commitment should hide the secret")
+	}
+	plain := make([]Share, len(shares))
+	for i, s := range shares {
+		if !commitment.Verify(s) {
+			t.Fatalf("share %d is invalid", s.Index)
+		}
+		plain[i] = s.Share()
+	}
+	got, err := Reconstruct(plain[2:])
+	if err != nil || !got.Equals(secret) {
+		t.Fatalf("Reconstruct failed: %v", err)
+	}
+	v, err := Interpolate(plain[:3], 5)
+	if err != nil || !v.Equals(shares[4].Value) {
+		t.Fatalf("Interpolate failed: %v", err)
+	}
+
+	bad := shares[0]
+	bad.Blinding = bad.Blinding.Add(curve.ONE)
+	if commitment.Verify(bad) {
+		t.Fatalf("share with a modified blinding should be invalid")
+	}
+
+	shares2, commitment2, _ := SplitPedersen(curve.SampleScalar(), 3, 5, nil)
+	sum, err := commitment.Add(commitment2)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	s := PedersenShare{
+		Index:    2,
+		Value:    shares[1].Value.Add(shares2[1].Value),
+		Blinding: shares[1].Blinding.Add(shares2[1].Blinding),
+	}
+	if !sum.Verify(s) {
+		t.Fatalf("summed share is invalid")
+	}
+
+	b := shares[3].Bytes()
+	decoded, err := PedersenShareFromBytes(b)
+	if err != nil || decoded != shares[3] {
+		t.Fatalf("share round trip failed: %v", err)
+	}
+	if _, err := PedersenShareFromBytes(b[:ShareSize]); err == nil {
+		t.Fatalf("share with invalid length should be rejected")
+	}
+	decodedC, err := PedersenCommitmentFromBytes(commitment.Bytes())
+	if err != nil || !bytes.Equal(decodedC.Bytes(), commitment.Bytes()) {
+		t.Fatalf("commitment round trip failed: %v", err)
+	}
+	if _, err := CommitPedersen(make(Polynomial, 2), make(Polynomial, 3)); err == nil {
+		t.Fatalf("polynomials of different degrees should be rejected")
+	}
+}
+
+func TestPedersenRandomness(t *testing.T) {
+	// Two coefficients, then the three blinding coefficients, of 80 bytes
+	// each.
+	seed := bytes.Repeat([]byte{7}, 400)
+	secret := curve.SampleScalar()
+	s1, c1, err := SplitPedersen(secret, 3, 5, bytes.NewReader(seed))
+	if err != nil {
+		t.Fatalf("SplitPedersen failed: %v", err)
+	}
+	s2, c2, _ := SplitPedersen(secret, 3, 5, bytes.NewReader(seed))
+	for i := range c1 {
+		if !c1[i].Equals(c2[i]) {
+			t.Fatalf("SplitPedersen should be deterministic for a fixed random source")
+		}
+	}
+	if !s1[0].Blinding.Equals(s2[0].Blinding) {
+		t.Fatalf("SplitPedersen should be deterministic for a fixed random source")
+	}
+	if _, _, err := SplitPedersen(secret, 3, 5, bytes.NewReader(seed[:320])); err == nil {
+		t.Fatalf("SplitPedersen should report a failing random source")
+	}
+}
@@ -0,0 +1,168 @@
+package hd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+)
+
+// Version prefixes of serialized extended keys: the ASCII strings "ecgs"
+// (private) and "ecgp" (public).
+const (
+	PrivateVersion uint32 = 0x65636773
+	PublicVersion  uint32 = 0x65636770
+)
+
+// Length of a serialized extended key, in bytes:
+//
+//	version (4) || depth (1) || parent fingerprint (4) || child index (4)
+//	|| chain code (32) || key (40)
+//
+// with big-endian integers as in BIP32, the chain code as 32
+// little-endian bytes, and the key as a 40-byte scalar or encoded public
+// key.
+const ExtendedKeySize = 4 + 1 + 4 + 4 + 32 + 40
+
+// Bytes returns the serialization of the extended key.
+func (k *ExtendedKey) Bytes() []byte {
+	res := make([]byte, 0, ExtendedKeySize)
+	version := PublicVersion
+	if k.isPrivate {
+		version = PrivateVersion
+	}
+	res = binary.BigEndian.AppendUint32(res, version)
+	res = append(res, k.depth)
+	res = append(res, k.parentFingerprint[:]...)
+	res = binary.BigEndian.AppendUint32(res, k.childIndex)
+	res = append(res, k.chainCode.ToLittleEndianBytes()...)
+	if k.isPrivate {
+		return append(res, k.sk.ToLittleEndianBytes()...)
+	}
+	return append(res, k.pk.ToBytes()...)
+}
+
+// ExtendedKeyFromBytes decodes a serialized extended key.
+func ExtendedKeyFromBytes(b []byte) (*ExtendedKey, error) {
+	if len(b) != ExtendedKeySize {
+		return nil, fmt.Errorf("invalid extended key length, must be %d bytes", ExtendedKeySize)
+	}
+
+	k := &ExtendedKey{
+		depth:             b[4],
+		parentFingerprint: [4]byte(b[5:9]),
+		childIndex:        binary.BigEndian.Uint32(b[9:13]),
+		chainCode:         p2.EmptyHashOut(),
+		isPrivate:         false,
+		sk:                curve.ZERO,
+		pk:                curve.NEUTRAL_ECgFp5Point,
+	}
+	if k.depth == 0 && (k.parentFingerprint != [4]byte{} || k.childIndex != 0) {
+		return nil, errors.New("invalid master key metadata")
+	}
+	chainCode, err := p2.HashOutFromLittleEndianBytes(b[13:45])
+	if err != nil || !bytes.Equal(chainCode.ToLittleEndianBytes(), b[13:45]) {
+		return nil, errors.New("invalid chain code")
+	}
+	k.chainCode = chainCode
+
+	switch binary.BigEndian.Uint32(b) {
+	case PrivateVersion:
+		sk, err := curve.ScalarFromCanonicalBytes(b[45:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		if sk.Equals(curve.ZERO) {
+			return nil, errors.New("invalid private key: zero")
+		}
+		k.isPrivate, k.sk, k.pk = true, sk, curve.MulGen(sk)
+	case PublicVersion:
+		pk, err := curve.PointFromBytes(b[45:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		if pk.IsNeutral() {
+			return nil, errors.New("invalid public key: neutral")
+		}
+		k.pk = pk
+	default:
+		return nil, errors.New("unknown extended key version")
+	}
+	return k, nil
+}
+
+// String returns the Base58Check encoding of the serialized extended
+// key, with a double SHA-256 checksum as in BIP32.
+func (k *ExtendedKey) String() string {
+	payload := k.Bytes()
+	checksum := doubleSHA256(payload)
+	return base58Encode(append(payload, checksum[:4]...))
+}
+
+// ParseExtendedKey decodes an extended key from its Base58Check encoding.
+func ParseExtendedKey(s string) (*ExtendedKey, error) {
+	b, err := base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != ExtendedKeySize+4 {
+		return nil, fmt.Errorf("invalid extended key length, must be %d bytes", ExtendedKeySize)
+	}
+	checksum := doubleSHA256(b[:ExtendedKeySize])
+	if !bytes.Equal(checksum[:4], b[ExtendedKeySize:]) {
+		return nil, errors.New("invalid extended key checksum")
+	}
+	return ExtendedKeyFromBytes(b[:ExtendedKeySize])
+}
+
+func doubleSHA256(b []byte) [32]byte {
+	h := sha256.Sum256(b)
+	return sha256.Sum256(h[:])
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var bigRadix = big.NewInt(58)
+
+// Base58 encoding, with a leading '1' for each leading zero byte.
+func base58Encode(b []byte) string {
+	x := new(big.Int).SetBytes(b)
+	mod := new(big.Int)
+	var res []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, bigRadix, mod)
+		res = append(res, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		res = append(res, base58Alphabet[0])
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return string(res)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	x := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		d := bytes.IndexByte([]byte(base58Alphabet), s[i])
+		if d < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", s[i])
+		}
+		x.Mul(x, bigRadix)
+		x.Add(x, big.NewInt(int64(d)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), x.Bytes()...), nil
+}
@@ -0,0 +1,253 @@
+// Package hd implements hierarchical deterministic derivation of ECgFp5
+// Schnorr keys, following the structure of BIP32 with Poseidon2 in place
+// of HMAC-SHA512.
+//
+// An extended key is a key (private or public) along with a 256-bit chain
+// code, represented as a Poseidon2 HashOut. The master key is derived from
+// a seed:
+//
+//	h = HashNToMNoPad(MASTER_TAG || PackBytes(seed), 14)
+//	sk = h[0..10] (as a 640-bit integer) mod n
+//	chain code = h[10..14]
+//
+// and child i of a parent key (k, c) with public key K is derived with:
+//
+//	data = 0 || limbs(k)    if i is hardened (i >= 2^31)
+//	data = 1 || K           otherwise
+//	h = HashNToMNoPad(CHILD_TAG || c || data || i, 14)
+//	t = h[0..10] mod n
+//	child key: k + t (private), K + t*G (public)
+//	child chain code = h[10..14]
+//
+// where limbs(k) are the ten 32-bit limbs of the secret scalar and K is
+// the encoded public key. Non-hardened children can thus be derived from
+// the parent public key alone, while hardened children need the parent
+// private key. As in BIP32, the (negligible) case of an invalid child key
+// is reported as an error, and the caller should proceed with the next
+// index.
+package hd
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	g "github.com/elliottech/poseidon_crypto/field/goldilocks"
+	p2 "github.com/elliottech/poseidon_crypto/hash/poseidon2_goldilocks_plonky2"
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+)
+
+// Domain separation tags, as ASCII strings read as little-endian 64-bit
+// integers.
+const (
+	// "hd-mastr": master key derivation.
+	MASTER_TAG = g.GoldilocksField(0x727473616d2d6468)
+	// "hd-child": child key derivation.
+	CHILD_TAG = g.GoldilocksField(0x646c6968632d6468)
+)
+
+// Index of the first hardened child.
+const HardenedOffset uint32 = 1 << 31
+
+// Bounds on the seed length, in bytes.
+const (
+	MinSeedSize = 16
+	MaxSeedSize = 64
+)
+
+// ExtendedKey is a private or public extended key, with the metadata of
+// its position in the hierarchy. A private extended key holds secret
+// values.
+type ExtendedKey struct {
+	depth             uint8
+	parentFingerprint [4]byte
+	childIndex        uint32
+	chainCode         p2.HashOut
+
+	isPrivate bool
+	sk        curve.ECgFp5Scalar
+	pk        curve.ECgFp5Point
+}
+
+// NewMasterKey derives the master private key from a seed of 16 to 64
+// bytes (e.g. the output of a BIP39 mnemonic).
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < MinSeedSize || len(seed) > MaxSeedSize {
+		return nil, fmt.Errorf("invalid seed length, must be between %d and %d bytes", MinSeedSize, MaxSeedSize)
+	}
+	input := make([]g.GoldilocksField, 0, 2+(len(seed)+g.PACKED_BYTES-1)/g.PACKED_BYTES)
+	input = append(input, MASTER_TAG)
+	input = append(input, g.PackBytes(seed)...)
+
+	sk, chainCode := derive(input)
+	if sk.Equals(curve.ZERO) {
+		return nil, errors.New("invalid master key, use another seed")
+	}
+	return &ExtendedKey{
+		depth:             0,
+		parentFingerprint: [4]byte{},
+		childIndex:        0,
+		chainCode:         chainCode,
+		isPrivate:         true,
+		sk:                sk,
+		pk:                curve.MulGen(sk),
+	}, nil
+}
+
+// Hash the input to a scalar and a chain code.
+func derive(input []g.GoldilocksField) (curve.ECgFp5Scalar, p2.HashOut) {
+	h := p2.HashNToMNoPad(input, 14)
+	var wide [10]uint64
+	for i := range wide {
+		wide[i] = h[i].ToCanonicalUint64()
+	}
+	var chainCode p2.HashOut
+	copy(chainCode[:], h[10:])
+	return curve.ScalarFromUint640(wide), chainCode
+}
+
+// Child derives the child key with the provided index; indices from
+// HardenedOffset on are hardened, and need a private key.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if k.depth == 255 {
+		return nil, errors.New("maximum derivation depth reached")
+	}
+	input := make([]g.GoldilocksField, 0, 1+4+1+10+1)
+	input = append(input, CHILD_TAG)
+	input = append(input, k.chainCode[:]...)
+	if index >= HardenedOffset {
+		if !k.isPrivate {
+			return nil, errors.New("cannot derive a hardened child from a public key")
+		}
+		limbs := k.sk.SplitTo32BitLimbs()
+		input = append(input, 0)
+		input = append(input, limbs[:]...)
+	} else {
+		input = append(input, 1)
+		for _, v := range k.pk.Encode().ToUint64Array() {
+			input = append(input, g.GoldilocksField(v))
+		}
+	}
+	input = append(input, g.GoldilocksField(uint64(index)))
+
+	tweak, chainCode := derive(input)
+	child := &ExtendedKey{
+		depth:             k.depth + 1,
+		parentFingerprint: k.Fingerprint(),
+		childIndex:        index,
+		chainCode:         chainCode,
+		isPrivate:         k.isPrivate,
+		sk:                curve.ZERO,
+		pk:                curve.NEUTRAL_ECgFp5Point,
+	}
+	if k.isPrivate {
+		child.sk = k.sk.Add(tweak)
+		if child.sk.Equals(curve.ZERO) {
+			return nil, fmt.Errorf("invalid child key at index %d, use the next index", index)
+		}
+		child.pk = curve.MulGen(child.sk)
+	} else {
+		child.pk = k.pk.Add(curve.MulGen(tweak))
+		if child.pk.IsNeutral() {
+			return nil, fmt.Errorf("invalid child key at index %d, use the next index", index)
+		}
+	}
+	return child, nil
+}
+
+// DerivePath derives the descendant key at the provided path, relative
+// to this key; see ParsePath() for the syntax.
+func (k *ExtendedKey) DerivePath(path string) (*ExtendedKey, error) {
+	indices, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(indices) > 0 && k.depth != 0 {
+		return nil, errors.New("absolute path must be derived from the master key")
+	}
+	return k.DeriveIndices(indices)
+}
+
+// DeriveIndices derives the descendant key at the provided sequence of
+// child indices.
+func (k *ExtendedKey) DeriveIndices(indices []uint32) (*ExtendedKey, error) {
+	res := k
+	for _, i := range indices {
+		child, err := res.Child(i)
+		if err != nil {
+			return nil, err
+		}
+		res = child
+	}
+	return res, nil
+}
+
+// Neuter returns the public extended key for this key.
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	res := *k
+	res.isPrivate = false
+	res.sk = curve.ZERO
+	return &res
+}
+
+// IsPrivate returns whether this is a private extended key.
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.isPrivate
+}
+
+// Depth returns the number of derivations from the master key.
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
+
+// ChildIndex returns the index of this key in its parent, or 0 for the
+// master key.
+func (k *ExtendedKey) ChildIndex() uint32 {
+	return k.childIndex
+}
+
+// ParentFingerprint returns the fingerprint of the parent key, or zero
+// for the master key.
+func (k *ExtendedKey) ParentFingerprint() [4]byte {
+	return k.parentFingerprint
+}
+
+// ChainCode returns the chain code.
+func (k *ExtendedKey) ChainCode() p2.HashOut {
+	return k.chainCode
+}
+
+// Fingerprint identifies the key: the first 4 bytes of the Poseidon2
+// hash of the encoded public key.
+func (k *ExtendedKey) Fingerprint() [4]byte {
+	input := make([]g.GoldilocksField, 0, 5)
+	for _, v := range k.pk.Encode().ToUint64Array() {
+		input = append(input, g.GoldilocksField(v))
+	}
+	var res [4]byte
+	copy(res[:], p2.HashNoPad(input).ToLittleEndianBytes())
+	return res
+}
+
+// PrivateKey returns the Schnorr private key of a private extended key.
+func (k *ExtendedKey) PrivateKey() (*schnorr.PrivateKey, error) {
+	if !k.isPrivate {
+		return nil, errors.New("not a private extended key")
+	}
+	return schnorr.NewPrivateKey(k.sk)
+}
+
+// PublicKey returns the Schnorr public key.
+func (k *ExtendedKey) PublicKey() *schnorr.PublicKey {
+	// The point is a valid group element other than the neutral, so this
+	// cannot fail.
+	pk, _ := schnorr.NewPublicKey(k.pk.Encode())
+	return pk
+}
+
+// Equal reports whether both extended keys are identical, in constant
+// time for private keys.
+func (k *ExtendedKey) Equal(other *ExtendedKey) bool {
+	return subtle.ConstantTimeCompare(k.Bytes(), other.Bytes()) == 1
+}
@@ -0,0 +1,243 @@
+package hd
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	gFp5 "github.com/elliottech/poseidon_crypto/field/goldilocks_quintic_extension"
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex: %v", err)
+	}
+	return b
+}
+
+// Same seeds and paths as the test vectors 1 and 2 of BIP32.
+func TestVectors(t *testing.T) {
+	m, err := NewMasterKey(mustHex(t, "000102030405060708090a0b0c0d0e0f"))
+	if err != nil {
+		t.Fatalf("NewMasterKey failed: %v", err)
+	}
+	for _, v := range []struct{ path, xprv, xpub string }{
+		{
+			"m",
+			"4bopYsjzivuDzn6zTui4CH6wrd5WkxRab5bPnrcNMnENFGhjzLtLUCVnB7vSJBLR3Z9QMYhJnt56vfLFaQ65pqtUSUJYrvK4wLMNhpguUWr18B4o7Bq1eELyw2",
+			"4bopYoaHQCXL2SAerx8F2cZnqgxv8cqVN6U1RnHzkJK7S6ziatXyBbWLU4SqjpQ15y6qcUDFn9zVaZg3GW49LdZHG1iFzTQnVX1bHRQ5JHTBdPQ2WnKwToBTtD",
+		},
+		{
+			"m/0'",
+			"4bopYskTZYuVJFXzxmQz8L4ycyHezS2ygCNs4HPTrQaYWGZBfhuTsr4qNKdeCPodoZ8tfR12tq3NussPP1BhoFXjDCpxz6HCUmChRi2GKJVzA6WiBX1SrTyS14",
+			"4bopYoakEpXbKubfMoqAxfXpc3B4N6StTDFUhD56EvfHh6rAGFZ6bF5PfGA3e1u6eEFPSfHHhcLuUuZFX5XmjXHaawTQkdxPi5iShzKbZooeCNxSw73YtgYxgS",
+		},
+		{
+			"m/0'/1",
+			"4bopYskq7KQwo4sMXw4Be5mms1PqP6XWppaDMq5bAhAZTTSNa2VkxQ3WDGbDEpUGb18DZumiKmUCG32kv3RH5NLmpW2H1tY3MDEdfY55KW1GQRLPq1TqmbJhtw",
+			"4bopYob7nb33piw1vyUNUREcr5HEkkwRbqSpzkmDZDFJeHjMAa9Pfo44WD7cgS6oHaU7kHmBTfEVHRVyDXEnwgPyKTC9Wy4NemZoKd76Cv7D5NqRpuXEgFamYx",
+		},
+		{
+			"m/0'/1/2'",
+			"4bopYsm3rj6FojomeSCvLzvsWFGU3Ybrb15D2YmPPHYcJznTSW9DYwcdXEKxJSekQ1FpWdNXRbkqZ78PePotQjEZtHqdoDB18Cq6SgWDTPkgP4RPKJREgtWDPe",
+			"4bopYobLXziMqPsS3Ud7BLPiVK9sRD1mN1wpfUT1modMVq5S33nrGLdBpArMk3sXX1HyvoM69jFdSRrT1yAAwkhgSsoZhsaEBdaPeNBvoLA36dxHHJ2Dm91Sdu",
+		},
+		{
+			"m/0'/1/2'/2",
+			"4bopYsmToDxLGJEBJFqTB2ZodaPYG9Ri4g78G743HEXPPfXEvhVAJywMaMorNwpbhqSqKheAjGqdxhi9vMjxbRcjsyfy43NPQkVGcA8K2YsAYaxda29TZYMMNa",
+			"4bopYobkUVaSHxHqhJFe1N2eceGwdoqcqgyju2jffkc8aVpDXF8o2NwusJLFpZeGjrF164oTAAGiwKgNoznzLScf1fkbsVNcX8oHfjkAAoHQt8Sv1PzMo6WSsL",
+		},
+		{
+			"m/0'/1/2'/2/1000000000",
+			"4bopYsmjUmCWU25KsXsnBwPU3V1LHuG68xSnr2e3wB95V1zL4V8wJR9eBwnDNyA6TC4iGY9sboR1M9MSeUqnDwKhq9jcNBDq7AfQfzateNwWskja33UEvmWWJb",
+			"4bopYoc2A2pcVg8zGaHy2GrK2YtjfZfzuyKQUxKgKhDpfrHJf2na1pACUtJcpbA8sHwLmNQSb2xD7ReCX2r6oY3DByPHmdhufiwkBAXQZuesuzFAXgmXV1T3G8",
+		},
+	} {
+		k, err := m.DerivePath(v.path)
+		if err != nil {
+			t.Fatalf("DerivePath(%s) failed: %v", v.path, err)
+		}
+		if k.String() != v.xprv {
+			t.Fatalf("%s: got private key %s", v.path, k.String())
+		}
+		if k.Neuter().String() != v.xpub {
+			t.Fatalf("%s: got public key %s", v.path, k.Neuter().String())
+		}
+	}
+
+	m, err = NewMasterKey(mustHex(t, "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542"))
+	if err != nil {
+		t.Fatalf("NewMasterKey failed: %v", err)
+	}
+	for _, v := range []struct{ path, sk, chainCode string }{
+		{"m", "dadfd873649fcd2483040f513b967a3d939178669bcff37e138675c043f7f2c5053a01e524bf4a58", "5882729f8860fcff9ba69edc10d28e0f6f4a6de334f226cfc3339db4e5941a93"},
+		{"m/0", "533df1dd405ee522bea3aa631ae16b2a9a7a67554ea5dbf097cdc9687728005beb3fed0a57ad5639", "668882d5be156b74d92f7f66cfc4021a96cb81b2fc57ab6fafc73b86752214f5"},
+		{"m/0/2147483647'", "dc520754691fa66cc257180815feb91683561cef063ee65ac8b3d767053067fdfaf0afec01f70a5a", "1b257830f29ce0fe8a8943a47fce7f8cae603302e8b0fdf689544f7e892d9eba"},
+		{"m/44'/0'/3", "045bd150ddc1fbed9340c75b6df454fdc04431e3b30266b7441279c9d613a8b2da85e0d192718051", "c64df4a4ea7d2db404454db7a2dc5452eae3ef5a068ffb22b866728e9ca45508"},
+	} {
+		k, err := m.DerivePath(v.path)
+		if err != nil {
+			t.Fatalf("DerivePath(%s) failed: %v", v.path, err)
+		}
+		sk, err := k.PrivateKey()
+		if err != nil {
+			t.Fatalf("PrivateKey failed: %v", err)
+		}
+		if hex.EncodeToString(sk.Bytes()) != v.sk {
+			t.Fatalf("%s: got private key %x", v.path, sk.Bytes())
+		}
+		if hex.EncodeToString(k.ChainCode().ToLittleEndianBytes()) != v.chainCode {
+			t.Fatalf("%s: got chain code %x", v.path, k.ChainCode().ToLittleEndianBytes())
+		}
+	}
+}
+
+func TestPublicDerivation(t *testing.T) {
+	m, _ := NewMasterKey(mustHex(t, "000102030405060708090a0b0c0d0e0f"))
+	account, err := m.DerivePath("m/44'/0'/3'")
+	if err != nil {
+		t.Fatalf("DerivePath failed: %v", err)
+	}
+	xpub := account.Neuter()
+	if xpub.IsPrivate() {
+		t.Fatalf("neutered key should be public")
+	}
+	if _, err := xpub.PrivateKey(); err == nil {
+		t.Fatalf("public extended key should have no private key")
+	}
+	if _, err := xpub.Child(HardenedOffset); err == nil {
+		t.Fatalf("hardened derivation from a public key should fail")
+	}
+
+	// Non-hardened children derived from the public key match those
+	// derived from the private key.
+	for _, path := range [][]uint32{{0}, {1, 7}, {0, HardenedOffset - 1}} {
+		priv, err := account.DeriveIndices(path)
+		if err != nil {
+			t.Fatalf("DeriveIndices failed: %v", err)
+		}
+		pub, err := xpub.DeriveIndices(path)
+		if err != nil {
+			t.Fatalf("DeriveIndices failed: %v", err)
+		}
+		if !priv.Neuter().Equal(pub) {
+			t.Fatalf("public derivation of %v differs from private derivation", path)
+		}
+		if pub.ParentFingerprint() == [4]byte{} || pub.Depth() != uint8(3+len(path)) {
+			t.Fatalf("unexpected metadata for %v", path)
+		}
+
+		// The derived private key signs for the derived public key.
+		sk, _ := priv.PrivateKey()
+		hashedMsg := gFp5.Sample()
+		sig := sk.SignHashedMessage(hashedMsg)
+		if !schnorr.IsSchnorrSignatureValid(pub.PublicKey().Element(), hashedMsg, sig) {
+			t.Fatalf("signature of the derived key is invalid")
+		}
+	}
+
+	child, _ := account.Child(5)
+	if child.ParentFingerprint() != account.Fingerprint() || child.ChildIndex() != 5 {
+		t.Fatalf("unexpected child metadata")
+	}
+	hardened, _ := account.Child(5 + HardenedOffset)
+	if hardened.PublicKey().Equal(child.PublicKey()) {
+		t.Fatalf("hardened and non-hardened children should differ")
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	m, _ := NewMasterKey(mustHex(t, "000102030405060708090a0b0c0d0e0f"))
+	k, _ := m.DerivePath("m/1'/2")
+	for _, key := range []*ExtendedKey{m, k, k.Neuter()} {
+		b := key.Bytes()
+		if len(b) != ExtendedKeySize {
+			t.Fatalf("unexpected length %d", len(b))
+		}
+		decoded, err := ExtendedKeyFromBytes(b)
+		if err != nil {
+			t.Fatalf("ExtendedKeyFromBytes failed: %v", err)
+		}
+		if !decoded.Equal(key) || decoded.IsPrivate() != key.IsPrivate() {
+			t.Fatalf("round trip failed")
+		}
+		parsed, err := ParseExtendedKey(key.String())
+		if err != nil {
+			t.Fatalf("ParseExtendedKey failed: %v", err)
+		}
+		if !parsed.Equal(key) {
+			t.Fatalf("string round trip failed")
+		}
+	}
+
+	s := k.String()
+	corrupted := []byte(s)
+	corrupted[10] ^= 1
+	for _, bad := range []string{"", "0" + s[1:], string(corrupted), s[:len(s)-1]} {
+		if _, err := ParseExtendedKey(bad); err == nil {
+			t.Fatalf("ParseExtendedKey(%q) should fail", bad)
+		}
+	}
+
+	b := k.Bytes()
+	for name, mutate := range map[string]func([]byte){
+		"version":    func(b []byte) { b[0] ^= 1 },
+		"master":     func(b []byte) { b[4] = 0 },
+		"chain code": func(b []byte) { copy(b[13:21], []byte{1, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}) },
+		"zero key":   func(b []byte) { copy(b[45:], make([]byte, 40)) },
+		"length":     nil,
+	} {
+		bad := append([]byte(nil), b...)
+		if mutate == nil {
+			bad = bad[1:]
+		} else {
+			mutate(bad)
+		}
+		if _, err := ExtendedKeyFromBytes(bad); err == nil {
+			t.Fatalf("invalid %s should be rejected", name)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	for _, v := range []struct {
+		path    string
+		indices []uint32
+	}{
+		{"m", []uint32{}},
+		{"m/0", []uint32{0}},
+		{"m/44'/0'/3", []uint32{44 + HardenedOffset, HardenedOffset, 3}},
+		{"m/1h/2H/2147483647", []uint32{1 + HardenedOffset, 2 + HardenedOffset, HardenedOffset - 1}},
+	} {
+		indices, err := ParsePath(v.path)
+		if err != nil {
+			t.Fatalf("ParsePath(%s) failed: %v", v.path, err)
+		}
+		if !reflect.DeepEqual(indices, v.indices) {
+			t.Fatalf("ParsePath(%s) = %v", v.path, indices)
+		}
+	}
+	if FormatPath([]uint32{44 + HardenedOffset, HardenedOffset, 3}) != "m/44'/0'/3" {
+		t.Fatalf("unexpected FormatPath output")
+	}
+
+	for _, path := range []string{"", "/0", "n/0", "m/", "m//1", "m/2147483648", "m/-1", "m/+1", "m/1''", "m/x", "m/0/"} {
+		if _, err := ParsePath(path); err == nil {
+			t.Fatalf("ParsePath(%q) should fail", path)
+		}
+	}
+
+	m, _ := NewMasterKey(mustHex(t, "000102030405060708090a0b0c0d0e0f"))
+	k, _ := m.Child(0)
+	if _, err := k.DerivePath("m/1"); err == nil {
+		t.Fatalf("absolute path from a child key should fail")
+	}
+	if _, err := NewMasterKey(make([]byte, MinSeedSize-1)); err == nil {
+		t.Fatalf("short seed should be rejected")
+	}
+	if _, err := NewMasterKey(make([]byte, MaxSeedSize+1)); err == nil {
+		t.Fatalf("long seed should be rejected")
+	}
+}
@@ -0,0 +1,56 @@
+package hd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsePath parses a derivation path such as "m/44'/0'/3" into child
+// indices. Hardened indices are marked with a trailing ', h or H, and
+// must be lower than 2^31; "m" alone is the master key.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, errors.New("derivation path must start with \"m\"")
+	}
+
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := false
+		if n := len(part); n > 0 && (part[n-1] == '\'' || part[n-1] == 'h' || part[n-1] == 'H') {
+			hardened = true
+			part = part[:n-1]
+		}
+		if part == "" || part[0] == '+' || part[0] == '-' {
+			return nil, fmt.Errorf("invalid path component %q", part)
+		}
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(i) >= HardenedOffset {
+			return nil, fmt.Errorf("invalid path component %q", part)
+		}
+		if hardened {
+			i += uint64(HardenedOffset)
+		}
+		indices = append(indices, uint32(i))
+	}
+	return indices, nil
+}
+
+// FormatPath formats child indices as a derivation path, with hardened
+// indices marked with a trailing '.
+func FormatPath(indices []uint32) string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, i := range indices {
+		sb.WriteByte('/')
+		if i >= HardenedOffset {
+			sb.WriteString(strconv.FormatUint(uint64(i-HardenedOffset), 10))
+			sb.WriteByte('\'')
+		} else {
+			sb.WriteString(strconv.FormatUint(uint64(i), 10))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,395 @@
+// Package keystore implements a password-encrypted JSON file format for
+// ECgFp5 Schnorr private keys, in the spirit of the Ethereum keystore v3:
+//
+//	{
+//	  "version": 1,
+//	  "id": "<random UUID>",
+//	  "publicKey": "<hex of the 40-byte encoded public key>",
+//	  "crypto": {
+//	    "cipher": "aes-256-gcm",
+//	    "cipherparams": {"nonce": "<hex>"},
+//	    "ciphertext": "<hex>",
+//	    "kdf": "pbkdf2",
+//	    "kdfparams": {"prf": "hmac-sha256", "c": 600000, "dklen": 64, "salt": "<hex>"},
+//	    "mac": "<hex>"
+//	  }
+//	}
+//
+// The public key is the encoding of SchnorrPkFromSk(), in plaintext so that
+// keystores can be looked up without the password. The derived key is
+// split into an encryption key dk[0..32], used with AES-256-GCM with the
+// public key as additional data, and a MAC key dk[32..64]:
+//
+//	mac = HMAC-SHA256(dk[32..64], version || public key || nonce || ciphertext)
+//
+// The MAC is checked first, so that a wrong password is reported as
+// ErrWrongPassword rather than as a decryption failure.
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+)
+
+// Version of the keystore format.
+const Version = 1
+
+// Supported algorithms.
+const (
+	CipherAES256GCM = "aes-256-gcm"
+	KDFPBKDF2       = "pbkdf2"
+	PRFHMACSHA256   = "hmac-sha256"
+)
+
+const (
+	derivedKeyLen = 64
+	saltLen       = 32
+	// Bound on the iteration count, about 17 times DefaultOptions: a crafted
+	// file can make a decryption take several seconds, but not minutes.
+	maxIterations = 10_000_000
+)
+
+// ErrWrongPassword is returned when the MAC does not match, which means
+// that the password is wrong (or that the file was tampered with).
+var ErrWrongPassword = errors.New("keystore: wrong password")
+
+// KDFParams are the parameters of the key derivation function.
+type KDFParams struct {
+	PRF        string `json:"prf"`
+	Iterations int    `json:"c"`
+	DKLen      int    `json:"dklen"`
+	Salt       string `json:"salt"`
+}
+
+// CipherParams are the parameters of the cipher.
+type CipherParams struct {
+	Nonce string `json:"nonce"`
+}
+
+// CryptoJSON is the encrypted part of a keystore.
+type CryptoJSON struct {
+	Cipher       string       `json:"cipher"`
+	CipherParams CipherParams `json:"cipherparams"`
+	Ciphertext   string       `json:"ciphertext"`
+	KDF          string       `json:"kdf"`
+	KDFParams    KDFParams    `json:"kdfparams"`
+	MAC          string       `json:"mac"`
+}
+
+// Keystore is an encrypted private key, as stored in a keystore file.
+type Keystore struct {
+	Version   int        `json:"version"`
+	ID        string     `json:"id"`
+	PublicKey string     `json:"publicKey"`
+	Crypto    CryptoJSON `json:"crypto"`
+}
+
+// Options select the strength of the key derivation.
+type Options struct {
+	// Number of PBKDF2 iterations.
+	Iterations int
+}
+
+// DefaultOptions are the recommended options for new keystores: 600000
+// iterations of PBKDF2-HMAC-SHA256, as recommended by OWASP.
+var DefaultOptions = Options{Iterations: 600_000}
+
+// Encrypt encrypts the private key with the password.
+func Encrypt(sk *schnorr.PrivateKey, password []byte, opts Options) (*Keystore, error) {
+	if opts.Iterations < 1 || opts.Iterations > maxIterations {
+		return nil, fmt.Errorf("keystore: iteration count must be between 1 and %d", maxIterations)
+	}
+	var salt [saltLen]byte
+	var nonce [12]byte
+	var id [16]byte
+	for _, b := range [][]byte{salt[:], nonce[:], id[:]} {
+		if _, err := cryptorand.Read(b); err != nil {
+			return nil, fmt.Errorf("keystore: failed to read random bytes: %w", err)
+		}
+	}
+
+	pk := sk.PublicKey().Bytes()
+	dk, err := pbkdf2.Key(sha256.New, string(password), salt[:], opts.Iterations, derivedKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: failed to derive the key: %w", err)
+	}
+	aead, err := newAEAD(dk[:32])
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce[:], sk.Bytes(), pk)
+
+	return &Keystore{
+		Version:   Version,
+		ID:        formatUUID(id),
+		PublicKey: hex.EncodeToString(pk),
+		Crypto: CryptoJSON{
+			Cipher:       CipherAES256GCM,
+			CipherParams: CipherParams{Nonce: hex.EncodeToString(nonce[:])},
+			Ciphertext:   hex.EncodeToString(ciphertext),
+			KDF:          KDFPBKDF2,
+			KDFParams: KDFParams{
+				PRF:        PRFHMACSHA256,
+				Iterations: opts.Iterations,
+				DKLen:      derivedKeyLen,
+				Salt:       hex.EncodeToString(salt[:]),
+			},
+			MAC: hex.EncodeToString(computeMAC(dk[32:], Version, pk, nonce[:], ciphertext)),
+		},
+	}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func computeMAC(key []byte, version int, pk, nonce, ciphertext []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	var v [4]byte
+	binary.BigEndian.PutUint32(v[:], uint32(version)) //nolint:gosec
+	mac.Write(v[:])
+	mac.Write(pk)
+	mac.Write(nonce)
+	mac.Write(ciphertext)
+	return mac.Sum(nil)
+}
+
+// Random (version 4) UUID.
+func formatUUID(b [16]byte) string {
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// Decoded binary fields of a keystore.
+type decoded struct {
+	pk, salt, nonce, ciphertext, mac []byte
+}
+
+// Check the format of the keystore, and decode its binary fields.
+func (ks *Keystore) decode() (*decoded, error) {
+	if ks.Version != Version {
+		return nil, fmt.Errorf("keystore: unsupported version %d", ks.Version)
+	}
+	c := &ks.Crypto
+	if c.Cipher != CipherAES256GCM {
+		return nil, fmt.Errorf("keystore: unsupported cipher %q", c.Cipher)
+	}
+	if c.KDF != KDFPBKDF2 || c.KDFParams.PRF != PRFHMACSHA256 {
+		return nil, fmt.Errorf("keystore: unsupported key derivation %q with %q", c.KDF, c.KDFParams.PRF)
+	}
+	if c.KDFParams.Iterations < 1 || c.KDFParams.Iterations > maxIterations {
+		return nil, errors.New("keystore: invalid iteration count")
+	}
+	if c.KDFParams.DKLen != derivedKeyLen {
+		return nil, errors.New("keystore: invalid derived key length")
+	}
+
+	d := &decoded{}
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *[]byte
+		size  int
+	}{
+		{"public key", ks.PublicKey, &d.pk, schnorr.PublicKeySize},
+		{"salt", c.KDFParams.Salt, &d.salt, -1},
+		{"nonce", c.CipherParams.Nonce, &d.nonce, 12},
+		{"ciphertext", c.Ciphertext, &d.ciphertext, schnorr.PrivateKeySize + 16},
+		{"mac", c.MAC, &d.mac, sha256.Size},
+	} {
+		b, err := hex.DecodeString(f.value)
+		if err != nil || (f.size >= 0 && len(b) != f.size) || len(b) == 0 {
+			return nil, fmt.Errorf("keystore: invalid %s", f.name)
+		}
+		*f.dst = b
+	}
+	return d, nil
+}
+
+// PublicKeyBytes returns the plaintext public key of the keystore.
+func (ks *Keystore) PublicKeyBytes() ([]byte, error) {
+	d, err := ks.decode()
+	if err != nil {
+		return nil, err
+	}
+	return d.pk, nil
+}
+
+// Decrypt decrypts the private key with the password. It returns
+// ErrWrongPassword if the password is wrong.
+func (ks *Keystore) Decrypt(password []byte) (*schnorr.PrivateKey, error) {
+	d, err := ks.decode()
+	if err != nil {
+		return nil, err
+	}
+	dk, err := pbkdf2.Key(sha256.New, string(password), d.salt, ks.Crypto.KDFParams.Iterations, derivedKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: failed to derive the key: %w", err)
+	}
+	if !hmac.Equal(computeMAC(dk[32:], ks.Version, d.pk, d.nonce, d.ciphertext), d.mac) {
+		return nil, ErrWrongPassword
+	}
+
+	aead, err := newAEAD(dk[:32])
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, d.nonce, d.ciphertext, d.pk)
+	if err != nil {
+		return nil, errors.New("keystore: decryption failed")
+	}
+	sk, err := schnorr.PrivateKeyFromBytes(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: invalid private key: %w", err)
+	}
+	if !bytes.Equal(sk.PublicKey().Bytes(), d.pk) {
+		return nil, errors.New("keystore: private key does not match the public key")
+	}
+	return sk, nil
+}
+
+// Parse decodes a keystore from its JSON encoding, and checks its format.
+func Parse(data []byte) (*Keystore, error) {
+	var ks Keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("keystore: invalid JSON: %w", err)
+	}
+	if _, err := ks.decode(); err != nil {
+		return nil, err
+	}
+	return &ks, nil
+}
+
+// Marshal returns the JSON encoding of the keystore.
+func (ks *Keystore) Marshal() ([]byte, error) {
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// MigrationHook decides, after a successful decryption, whether a
+// keystore should be re-encrypted with new options, e.g. when the
+// recommended iteration count increases.
+type MigrationHook func(ks *Keystore) (Options, bool)
+
+// UpgradeIterations returns a MigrationHook which re-encrypts keystores
+// with fewer iterations than opts.
+func UpgradeIterations(opts Options) MigrationHook {
+	return func(ks *Keystore) (Options, bool) {
+		return opts, ks.Crypto.KDFParams.Iterations < opts.Iterations
+	}
+}
+
+// Migrate decrypts the keystore and, if the hook requests it, returns a
+// new keystore encrypted with the new options and the same identifier;
+// otherwise the returned keystore is ks.
+func (ks *Keystore) Migrate(password []byte, hook MigrationHook) (*schnorr.PrivateKey, *Keystore, error) {
+	sk, err := ks.Decrypt(password)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts, ok := hook(ks)
+	if !ok {
+		return sk, ks, nil
+	}
+	migrated, err := Encrypt(sk, password, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrated.ID = ks.ID
+	return sk, migrated, nil
+}
+
+// Save encrypts the private key and writes the keystore to a file, which
+// must not exist yet.
+func Save(path string, sk *schnorr.PrivateKey, password []byte, opts Options) (*Keystore, error) {
+	ks, err := Encrypt(sk, password, opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ks.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return ks, f.Close()
+}
+
+// Load reads a keystore file and decrypts its private key. If hook is not
+// nil and requests a migration, the file is replaced with the migrated
+// keystore.
+func Load(path string, password []byte, hook MigrationHook) (*schnorr.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ks, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil {
+		return ks.Decrypt(password)
+	}
+
+	sk, migrated, err := ks.Migrate(password, hook)
+	if err != nil {
+		return nil, err
+	}
+	if migrated != ks {
+		if err := replaceFile(path, migrated); err != nil {
+			return nil, fmt.Errorf("keystore: migration failed: %w", err)
+		}
+	}
+	return sk, nil
+}
+
+// Atomically replace a keystore file.
+func replaceFile(path string, ks *Keystore) error {
+	data, err := ks.Marshal()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
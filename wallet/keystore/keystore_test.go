@@ -0,0 +1,210 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+)
+
+// Few iterations, to keep the tests fast.
+var testOptions = Options{Iterations: 1000}
+
+func newKey(t *testing.T) *schnorr.PrivateKey {
+	sk, err := schnorr.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	return sk
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	sk := newKey(t)
+	password := []byte("correct horse battery staple")
+	ks, err := Encrypt(sk, password, testOptions)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if ks.PublicKey != hex.EncodeToString(schnorr.SchnorrPkFromSk(sk.Scalar()).ToLittleEndianBytes()) {
+		t.Fatalf("public key does not match SchnorrPkFromSk()")
+	}
+	if len(ks.ID) != 36 || ks.ID[14] != '4' {
+		t.Fatalf("unexpected identifier %q", ks.ID)
+	}
+
+	data, err := ks.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	got, err := parsed.Decrypt(password)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if !got.Equal(sk) {
+		t.Fatalf("decrypted key differs")
+	}
+	pk, err := parsed.PublicKeyBytes()
+	if err != nil || !sk.PublicKey().Equal(mustPublicKey(t, pk)) {
+		t.Fatalf("PublicKeyBytes failed: %v", err)
+	}
+
+	if _, err := parsed.Decrypt([]byte("wrong")); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got %v", err)
+	}
+
+	// Two encryptions of the same key differ.
+	other, _ := Encrypt(sk, password, testOptions)
+	if other.Crypto.Ciphertext == ks.Crypto.Ciphertext || other.ID == ks.ID {
+		t.Fatalf("encryptions should be randomized")
+	}
+	if _, err := Encrypt(sk, password, Options{Iterations: 0}); err == nil {
+		t.Fatalf("zero iterations should be rejected")
+	}
+}
+
+func mustPublicKey(t *testing.T, b []byte) *schnorr.PublicKey {
+	pk, err := schnorr.PublicKeyFromBytes(b)
+	if err != nil {
+		t.Fatalf("PublicKeyFromBytes failed: %v", err)
+	}
+	return pk
+}
+
+func TestTampering(t *testing.T) {
+	sk := newKey(t)
+	password := []byte("password")
+	ks, _ := Encrypt(sk, password, testOptions)
+
+	flip := func(s string) string {
+		b, _ := hex.DecodeString(s)
+		b[0] ^= 1
+		return hex.EncodeToString(b)
+	}
+	// Modified authenticated fields are detected by the MAC.
+	for name, mutate := range map[string]func(ks *Keystore){
+		"ciphertext": func(ks *Keystore) { ks.Crypto.Ciphertext = flip(ks.Crypto.Ciphertext) },
+		"nonce":      func(ks *Keystore) { ks.Crypto.CipherParams.Nonce = flip(ks.Crypto.CipherParams.Nonce) },
+		"mac":        func(ks *Keystore) { ks.Crypto.MAC = flip(ks.Crypto.MAC) },
+		"salt":       func(ks *Keystore) { ks.Crypto.KDFParams.Salt = flip(ks.Crypto.KDFParams.Salt) },
+		"iterations": func(ks *Keystore) { ks.Crypto.KDFParams.Iterations++ },
+		"public key": func(ks *Keystore) { ks.PublicKey = hex.EncodeToString(newKey(t).PublicKey().Bytes()) },
+	} {
+		bad := *ks
+		mutate(&bad)
+		if _, err := bad.Decrypt(password); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("modified %s: expected ErrWrongPassword, got %v", name, err)
+		}
+	}
+
+	// Malformed keystores are rejected when parsing.
+	for name, mutate := range map[string]func(ks *Keystore){
+		"version":    func(ks *Keystore) { ks.Version = 2 },
+		"cipher":     func(ks *Keystore) { ks.Crypto.Cipher = "aes-128-ctr" },
+		"kdf":        func(ks *Keystore) { ks.Crypto.KDF = "scrypt" },
+		"prf":        func(ks *Keystore) { ks.Crypto.KDFParams.PRF = "hmac-sha512" },
+		"iterations": func(ks *Keystore) { ks.Crypto.KDFParams.Iterations = maxIterations + 1 },
+		"dklen":      func(ks *Keystore) { ks.Crypto.KDFParams.DKLen = 32 },
+		"public key": func(ks *Keystore) { ks.PublicKey = ks.PublicKey[2:] },
+		"nonce":      func(ks *Keystore) { ks.Crypto.CipherParams.Nonce = "zz" },
+		"salt":       func(ks *Keystore) { ks.Crypto.KDFParams.Salt = "" },
+		"mac":        func(ks *Keystore) { ks.Crypto.MAC = ks.Crypto.MAC[2:] },
+	} {
+		bad := *ks
+		mutate(&bad)
+		data, _ := json.Marshal(&bad)
+		if _, err := Parse(data); err == nil {
+			t.Fatalf("malformed %s should be rejected", name)
+		}
+	}
+	if _, err := Parse([]byte("{")); err == nil {
+		t.Fatalf("invalid JSON should be rejected")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key.json")
+	sk := newKey(t)
+	password := []byte("password")
+
+	if _, err := Save(path, sk, password, testOptions); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := Save(path, sk, password, testOptions); err == nil {
+		t.Fatalf("Save should not overwrite an existing file")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("unexpected file mode %v", info.Mode())
+	}
+
+	got, err := Load(path, password, nil)
+	if err != nil || !got.Equal(sk) {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := Load(path, []byte("wrong"), nil); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got %v", err)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json"), password, nil); err == nil {
+		t.Fatalf("loading a missing file should fail")
+	}
+}
+
+func TestMigration(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key.json")
+	sk := newKey(t)
+	password := []byte("password")
+	ks, _ := Save(path, sk, password, testOptions)
+
+	// No migration needed: the file is unchanged.
+	before, _ := os.ReadFile(path)
+	if _, err := Load(path, password, UpgradeIterations(testOptions)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	after, _ := os.ReadFile(path)
+	if string(before) != string(after) {
+		t.Fatalf("keystore should not be rewritten")
+	}
+
+	// Wrong password: no migration.
+	stronger := Options{Iterations: 2 * testOptions.Iterations}
+	if _, err := Load(path, []byte("wrong"), UpgradeIterations(stronger)); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got %v", err)
+	}
+
+	got, err := Load(path, password, UpgradeIterations(stronger))
+	if err != nil || !got.Equal(sk) {
+		t.Fatalf("Load with migration failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	migrated, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if migrated.Crypto.KDFParams.Iterations != stronger.Iterations || migrated.ID != ks.ID || migrated.PublicKey != ks.PublicKey {
+		t.Fatalf("unexpected migrated keystore")
+	}
+	if got, err := migrated.Decrypt(password); err != nil || !got.Equal(sk) {
+		t.Fatalf("migrated keystore does not decrypt: %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp") {
+			t.Fatalf("temporary file %s was left behind", e.Name())
+		}
+	}
+}
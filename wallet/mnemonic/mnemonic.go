@@ -0,0 +1,212 @@
+// Package mnemonic implements BIP39 mnemonic sentences with the English
+// wordlist, to back up ECgFp5 Schnorr keys as a list of words.
+//
+// Entropy of 128 to 256 bits (a multiple of 32) is extended with a
+// checksum made of the first bits of its SHA-256 hash (one bit per 32
+// bits of entropy), and split into groups of 11 bits, each encoding a word
+// of the wordlist. The mnemonic and an optional passphrase are stretched
+// into a 64-byte seed with PBKDF2-HMAC-SHA512:
+//
+//	seed = PBKDF2(HMAC-SHA512, mnemonic, "mnemonic" || passphrase, 2048, 64)
+//
+// The seed is then the input of the hierarchical deterministic
+// derivation of package wallet/hd, whose master private key is the key of
+// the mnemonic.
+//
+// BIP39 normalizes the mnemonic and the passphrase with Unicode NFKD.
+// English mnemonics are ASCII and unaffected; passphrases with non-ASCII
+// characters must be NFKD-normalized by the caller to be compatible with
+// other implementations.
+package mnemonic
+
+import (
+	"crypto/pbkdf2"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	curve "github.com/elliottech/poseidon_crypto/curve/ecgfp5"
+	schnorr "github.com/elliottech/poseidon_crypto/signature/schnorr"
+	"github.com/elliottech/poseidon_crypto/wallet/hd"
+)
+
+// Number of PBKDF2 iterations, and length of the seed in bytes.
+const (
+	SeedIterations = 2048
+	SeedSize       = 64
+)
+
+// The BIP39 English wordlist, one word per line.
+//
+//go:embed english.txt
+var englishTxt string
+
+var (
+	wordlist  = strings.Fields(englishTxt)
+	wordIndex = func() map[string]int {
+		m := make(map[string]int, len(wordlist))
+		for i, w := range wordlist {
+			m[w] = i
+		}
+		return m
+	}()
+)
+
+// Wordlist returns a copy of the English wordlist.
+func Wordlist() []string {
+	return append([]string(nil), wordlist...)
+}
+
+// NewEntropy returns bitSize bits of entropy from rand (or crypto/rand if
+// rand is nil); bitSize must be a multiple of 32 between 128 and 256.
+func NewEntropy(bitSize int, rand io.Reader) ([]byte, error) {
+	if err := checkEntropySize(bitSize); err != nil {
+		return nil, err
+	}
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	entropy := make([]byte, bitSize/8)
+	if _, err := io.ReadFull(rand, entropy); err != nil {
+		return nil, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return entropy, nil
+}
+
+func checkEntropySize(bitSize int) error {
+	if bitSize < 128 || bitSize > 256 || bitSize%32 != 0 {
+		return errors.New("entropy size must be a multiple of 32 bits between 128 and 256")
+	}
+	return nil
+}
+
+// Generate returns a new mnemonic for bitSize bits of entropy (12 words
+// for 128 bits, 24 words for 256 bits).
+func Generate(bitSize int, rand io.Reader) (string, error) {
+	entropy, err := NewEntropy(bitSize, rand)
+	if err != nil {
+		return "", err
+	}
+	return NewMnemonic(entropy)
+}
+
+// NewMnemonic encodes the entropy as a mnemonic.
+func NewMnemonic(entropy []byte) (string, error) {
+	bitSize := 8 * len(entropy)
+	if err := checkEntropySize(bitSize); err != nil {
+		return "", err
+	}
+
+	// Entropy bits followed by the checksum bits, read from the most
+	// significant bit of each byte.
+	h := sha256.Sum256(entropy)
+	data := append(append([]byte(nil), entropy...), h[0])
+	numWords := (bitSize + bitSize/32) / 11
+	words := make([]string, numWords)
+	for i := range words {
+		index := 0
+		for j := 0; j < 11; j++ {
+			bit := 11*i + j
+			index = index<<1 | int(data[bit/8]>>(7-bit%8)&1)
+		}
+		words[i] = wordlist[index]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// EntropyFromMnemonic decodes a mnemonic, and checks its checksum.
+func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	numWords := len(words)
+	if numWords < 12 || numWords > 24 || numWords%3 != 0 {
+		return nil, errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	}
+
+	// 11 bits per word, i.e. bitSize + bitSize/32 bits in total.
+	numBits := 11 * numWords
+	data := make([]byte, (numBits+7)/8)
+	for i, w := range words {
+		index, ok := wordIndex[w]
+		if !ok {
+			return nil, fmt.Errorf("invalid word %q at position %d", w, i+1)
+		}
+		for j := 0; j < 11; j++ {
+			if index>>(10-j)&1 == 1 {
+				bit := 11*i + j
+				data[bit/8] |= 1 << (7 - bit%8)
+			}
+		}
+	}
+
+	bitSize := 32 * numBits / 33
+	entropy := data[:bitSize/8]
+	h := sha256.Sum256(entropy)
+	checksumBits := bitSize / 32
+	mask := byte(0xff) << (8 - checksumBits)
+	if data[bitSize/8]&mask != h[0]&mask {
+		return nil, errors.New("invalid mnemonic checksum")
+	}
+	return entropy, nil
+}
+
+// Validate checks that the mnemonic has a valid length, only words of the
+// wordlist, and a valid checksum.
+func Validate(mnemonic string) error {
+	_, err := EntropyFromMnemonic(mnemonic)
+	return err
+}
+
+// NewSeed validates the mnemonic and stretches it, along with the
+// passphrase, into a 64-byte seed. The mnemonic is normalized to single
+// spaces between words.
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := Validate(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key(sha512.New, normalized, []byte("mnemonic"+passphrase), SeedIterations, SeedSize)
+}
+
+// MasterKey returns the master extended key of the mnemonic and
+// passphrase, from which account keys are derived.
+func MasterKey(mnemonic, passphrase string) (*hd.ExtendedKey, error) {
+	seed, err := NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return hd.NewMasterKey(seed)
+}
+
+// MasterScalar maps a seed to the master private key of the hierarchical
+// deterministic derivation: the same scalar as
+// hd.NewMasterKey(seed).PrivateKey().Scalar().
+func MasterScalar(seed []byte) (curve.ECgFp5Scalar, error) {
+	sk, err := masterPrivateKey(seed)
+	if err != nil {
+		return curve.ZERO, err
+	}
+	return sk.Scalar(), nil
+}
+
+// PrivateKey returns the master private key of the mnemonic and
+// passphrase; its public key is SchnorrPkFromSk() of MasterScalar().
+func PrivateKey(mnemonic, passphrase string) (*schnorr.PrivateKey, error) {
+	seed, err := NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return masterPrivateKey(seed)
+}
+
+func masterPrivateKey(seed []byte) (*schnorr.PrivateKey, error) {
+	master, err := hd.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return master.PrivateKey()
+}